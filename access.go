@@ -0,0 +1,495 @@
+package javaclass
+
+import (
+	"errors"
+	"strings"
+)
+
+// ClassAccess holds the access_flags of a class.
+type ClassAccess uint16
+
+const (
+	ClassPublic     ClassAccess = 0x0001
+	ClassFinal      ClassAccess = 0x0010
+	ClassSuper      ClassAccess = 0x0020
+	ClassInterface  ClassAccess = 0x0200
+	ClassAbstract   ClassAccess = 0x0400
+	ClassSynthetic  ClassAccess = 0x1000
+	ClassAnnotation ClassAccess = 0x2000
+	ClassEnum       ClassAccess = 0x4000
+	ClassModule     ClassAccess = 0x8000
+)
+
+func (a ClassAccess) IsPublic() bool {
+	return a&ClassPublic != 0
+}
+
+func (a ClassAccess) IsFinal() bool {
+	return a&ClassFinal != 0
+}
+
+func (a ClassAccess) IsSuper() bool {
+	return a&ClassSuper != 0
+}
+
+func (a ClassAccess) IsInterface() bool {
+	return a&ClassInterface != 0
+}
+
+func (a ClassAccess) IsAbstract() bool {
+	return a&ClassAbstract != 0
+}
+
+func (a ClassAccess) IsSynthetic() bool {
+	return a&ClassSynthetic != 0
+}
+
+func (a ClassAccess) IsAnnotation() bool {
+	return a&ClassAnnotation != 0
+}
+
+func (a ClassAccess) IsEnum() bool {
+	return a&ClassEnum != 0
+}
+
+func (a ClassAccess) IsModule() bool {
+	return a&ClassModule != 0
+}
+
+// String returns the modifiers and kind of the class as they would appear in
+// Java source, such as "public abstract class" or "public @interface".
+func (a ClassAccess) String() string {
+	if a.IsModule() {
+		return "module"
+	}
+	// These flags share their values with the corresponding InnerClassAccess flags.
+	return InnerClassAccess(a & (ClassPublic | ClassFinal | ClassInterface | ClassAbstract | ClassAnnotation | ClassEnum)).String()
+}
+
+// Validate checks for combinations of flags forbidden by JVMS 4.1.
+func (a ClassAccess) Validate() error {
+	switch {
+	case a.IsModule():
+		if a != ClassModule {
+			return ErrInvalidModuleFlags
+		}
+	case a.IsInterface():
+		if !a.IsAbstract() {
+			return ErrInterfaceNotAbstract
+		} else if a&(ClassFinal|ClassSuper|ClassEnum) != 0 {
+			return ErrInvalidInterfaceFlags
+		}
+	case a.IsAnnotation():
+		return ErrAnnotationNotInterface
+	case a.IsAbstract() && a.IsFinal():
+		return ErrAbstractFinal
+	}
+	return nil
+}
+
+// FieldAccess holds the access_flags of a field.
+type FieldAccess uint16
+
+const (
+	FieldPublic    FieldAccess = 0x0001
+	FieldPrivate   FieldAccess = 0x0002
+	FieldProtected FieldAccess = 0x0004
+	FieldStatic    FieldAccess = 0x0008
+	FieldFinal     FieldAccess = 0x0010
+	FieldVolatile  FieldAccess = 0x0040
+	FieldTransient FieldAccess = 0x0080
+	FieldSynthetic FieldAccess = 0x1000
+	FieldEnum      FieldAccess = 0x4000
+)
+
+func (a FieldAccess) IsPublic() bool {
+	return a&FieldPublic != 0
+}
+
+func (a FieldAccess) IsPrivate() bool {
+	return a&FieldPrivate != 0
+}
+
+func (a FieldAccess) IsProtected() bool {
+	return a&FieldProtected != 0
+}
+
+func (a FieldAccess) IsStatic() bool {
+	return a&FieldStatic != 0
+}
+
+func (a FieldAccess) IsFinal() bool {
+	return a&FieldFinal != 0
+}
+
+func (a FieldAccess) IsVolatile() bool {
+	return a&FieldVolatile != 0
+}
+
+func (a FieldAccess) IsTransient() bool {
+	return a&FieldTransient != 0
+}
+
+func (a FieldAccess) IsSynthetic() bool {
+	return a&FieldSynthetic != 0
+}
+
+func (a FieldAccess) IsEnum() bool {
+	return a&FieldEnum != 0
+}
+
+var fieldKeywords = []keyword[FieldAccess]{
+	{FieldPublic, "public"},
+	{FieldProtected, "protected"},
+	{FieldPrivate, "private"},
+	{FieldStatic, "static"},
+	{FieldFinal, "final"},
+	{FieldTransient, "transient"},
+	{FieldVolatile, "volatile"},
+}
+
+// String returns the modifiers of the field as they would appear in Java
+// source, such as "private static final".
+func (a FieldAccess) String() string {
+	return keywords(a, fieldKeywords)
+}
+
+// Validate checks for combinations of flags forbidden by JVMS 4.5 for a field
+// of a class with the given flags.
+func (a FieldAccess) Validate(owner ClassAccess) error {
+	if !oneVisibility(uint16(a)) {
+		return ErrConflictingVisibility
+	} else if owner.IsInterface() {
+		if a&(FieldPublic|FieldStatic|FieldFinal) != FieldPublic|FieldStatic|FieldFinal || a&(FieldVolatile|FieldTransient|FieldEnum) != 0 {
+			return ErrInvalidInterfaceMember
+		}
+	} else if a.IsFinal() && a.IsVolatile() {
+		return ErrFinalVolatile
+	}
+	return nil
+}
+
+// MethodAccess holds the access_flags of a method.
+type MethodAccess uint16
+
+const (
+	MethodPublic       MethodAccess = 0x0001
+	MethodPrivate      MethodAccess = 0x0002
+	MethodProtected    MethodAccess = 0x0004
+	MethodStatic       MethodAccess = 0x0008
+	MethodFinal        MethodAccess = 0x0010
+	MethodSynchronized MethodAccess = 0x0020
+	MethodBridge       MethodAccess = 0x0040
+	MethodVarargs      MethodAccess = 0x0080
+	MethodNative       MethodAccess = 0x0100
+	MethodAbstract     MethodAccess = 0x0400
+	MethodStrict       MethodAccess = 0x0800
+	MethodSynthetic    MethodAccess = 0x1000
+)
+
+func (a MethodAccess) IsPublic() bool {
+	return a&MethodPublic != 0
+}
+
+func (a MethodAccess) IsPrivate() bool {
+	return a&MethodPrivate != 0
+}
+
+func (a MethodAccess) IsProtected() bool {
+	return a&MethodProtected != 0
+}
+
+func (a MethodAccess) IsStatic() bool {
+	return a&MethodStatic != 0
+}
+
+func (a MethodAccess) IsFinal() bool {
+	return a&MethodFinal != 0
+}
+
+func (a MethodAccess) IsSynchronized() bool {
+	return a&MethodSynchronized != 0
+}
+
+func (a MethodAccess) IsBridge() bool {
+	return a&MethodBridge != 0
+}
+
+func (a MethodAccess) IsVarargs() bool {
+	return a&MethodVarargs != 0
+}
+
+func (a MethodAccess) IsNative() bool {
+	return a&MethodNative != 0
+}
+
+func (a MethodAccess) IsAbstract() bool {
+	return a&MethodAbstract != 0
+}
+
+func (a MethodAccess) IsStrict() bool {
+	return a&MethodStrict != 0
+}
+
+func (a MethodAccess) IsSynthetic() bool {
+	return a&MethodSynthetic != 0
+}
+
+var methodKeywords = []keyword[MethodAccess]{
+	{MethodPublic, "public"},
+	{MethodProtected, "protected"},
+	{MethodPrivate, "private"},
+	{MethodAbstract, "abstract"},
+	{MethodStatic, "static"},
+	{MethodFinal, "final"},
+	{MethodSynchronized, "synchronized"},
+	{MethodNative, "native"},
+	{MethodStrict, "strictfp"},
+}
+
+// String returns the modifiers of the method as they would appear in Java
+// source, such as "public static synchronized".
+func (a MethodAccess) String() string {
+	return keywords(a, methodKeywords)
+}
+
+// Validate checks for combinations of flags forbidden by JVMS 4.6 for a method
+// of a class with the given flags.
+func (a MethodAccess) Validate(owner ClassAccess) error {
+	if !oneVisibility(uint16(a)) {
+		return ErrConflictingVisibility
+	} else if owner.IsInterface() && (a&(MethodPublic|MethodPrivate) == 0 || a&(MethodProtected|MethodFinal|MethodSynchronized|MethodNative) != 0) {
+		return ErrInvalidInterfaceMember
+	} else if a.IsAbstract() && a&(MethodPrivate|MethodStatic|MethodFinal|MethodSynchronized|MethodNative) != 0 {
+		return ErrInvalidAbstractMethod
+	}
+	return nil
+}
+
+// InnerClassAccess holds the inner_class_access_flags of an InnerClasses entry.
+type InnerClassAccess uint16
+
+const (
+	InnerClassPublic     InnerClassAccess = 0x0001
+	InnerClassPrivate    InnerClassAccess = 0x0002
+	InnerClassProtected  InnerClassAccess = 0x0004
+	InnerClassStatic     InnerClassAccess = 0x0008
+	InnerClassFinal      InnerClassAccess = 0x0010
+	InnerClassInterface  InnerClassAccess = 0x0200
+	InnerClassAbstract   InnerClassAccess = 0x0400
+	InnerClassSynthetic  InnerClassAccess = 0x1000
+	InnerClassAnnotation InnerClassAccess = 0x2000
+	InnerClassEnum       InnerClassAccess = 0x4000
+)
+
+func (a InnerClassAccess) IsPublic() bool {
+	return a&InnerClassPublic != 0
+}
+
+func (a InnerClassAccess) IsPrivate() bool {
+	return a&InnerClassPrivate != 0
+}
+
+func (a InnerClassAccess) IsProtected() bool {
+	return a&InnerClassProtected != 0
+}
+
+func (a InnerClassAccess) IsStatic() bool {
+	return a&InnerClassStatic != 0
+}
+
+func (a InnerClassAccess) IsFinal() bool {
+	return a&InnerClassFinal != 0
+}
+
+func (a InnerClassAccess) IsInterface() bool {
+	return a&InnerClassInterface != 0
+}
+
+func (a InnerClassAccess) IsAbstract() bool {
+	return a&InnerClassAbstract != 0
+}
+
+func (a InnerClassAccess) IsSynthetic() bool {
+	return a&InnerClassSynthetic != 0
+}
+
+func (a InnerClassAccess) IsAnnotation() bool {
+	return a&InnerClassAnnotation != 0
+}
+
+func (a InnerClassAccess) IsEnum() bool {
+	return a&InnerClassEnum != 0
+}
+
+// String returns the modifiers and kind of the class as they would appear in
+// Java source, such as "private static final class".
+//
+// Modifiers implied by the kind, such as abstract for an interface, are
+// omitted.
+func (a InnerClassAccess) String() string {
+	kind := "class"
+	switch {
+	case a.IsAnnotation():
+		kind = "@interface"
+	case a.IsInterface():
+		kind = "interface"
+	case a.IsEnum():
+		kind = "enum"
+	}
+	var words []string
+	for _, k := range [...]keyword[InnerClassAccess]{
+		{InnerClassPublic, "public"},
+		{InnerClassProtected, "protected"},
+		{InnerClassPrivate, "private"},
+		{InnerClassAbstract, "abstract"},
+		{InnerClassStatic, "static"},
+		{InnerClassFinal, "final"},
+	} {
+		if a&k.Flag != 0 && (kind == "class" || k.Flag != InnerClassAbstract && k.Flag != InnerClassFinal) {
+			words = append(words, k.Word)
+		}
+	}
+	return strings.Join(append(words, kind), " ")
+}
+
+// Validate checks for combinations of flags that would be forbidden for a
+// class by JVMS 4.1.
+func (a InnerClassAccess) Validate() error {
+	switch {
+	case !oneVisibility(uint16(a)):
+		return ErrConflictingVisibility
+	case a.IsInterface():
+		if !a.IsAbstract() {
+			return ErrInterfaceNotAbstract
+		} else if a&(InnerClassFinal|InnerClassEnum) != 0 {
+			return ErrInvalidInterfaceFlags
+		}
+	case a.IsAnnotation():
+		return ErrAnnotationNotInterface
+	case a.IsAbstract() && a.IsFinal():
+		return ErrAbstractFinal
+	}
+	return nil
+}
+
+// ParameterAccess holds the access_flags of a MethodParameters entry.
+type ParameterAccess uint16
+
+const (
+	ParameterFinal     ParameterAccess = 0x0010
+	ParameterSynthetic ParameterAccess = 0x1000
+	ParameterMandated  ParameterAccess = 0x8000
+)
+
+func (a ParameterAccess) IsFinal() bool {
+	return a&ParameterFinal != 0
+}
+
+func (a ParameterAccess) IsSynthetic() bool {
+	return a&ParameterSynthetic != 0
+}
+
+func (a ParameterAccess) IsMandated() bool {
+	return a&ParameterMandated != 0
+}
+
+// String returns "final" for final parameters, and an empty string otherwise.
+func (a ParameterAccess) String() string {
+	return keywords(a, []keyword[ParameterAccess]{{ParameterFinal, "final"}})
+}
+
+// ModuleAccess holds the flags of a Module attribute, and of its exports and opens entries.
+type ModuleAccess uint16
+
+const (
+	ModuleOpen      ModuleAccess = 0x0020
+	ModuleSynthetic ModuleAccess = 0x1000
+	ModuleMandated  ModuleAccess = 0x8000
+)
+
+func (a ModuleAccess) IsOpen() bool {
+	return a&ModuleOpen != 0
+}
+
+func (a ModuleAccess) IsSynthetic() bool {
+	return a&ModuleSynthetic != 0
+}
+
+func (a ModuleAccess) IsMandated() bool {
+	return a&ModuleMandated != 0
+}
+
+// String returns "open" for open modules, and an empty string otherwise.
+func (a ModuleAccess) String() string {
+	return keywords(a, []keyword[ModuleAccess]{{ModuleOpen, "open"}})
+}
+
+// RequiresAccess holds the requires_flags of a Module attribute.
+type RequiresAccess uint16
+
+const (
+	RequiresTransitive  RequiresAccess = 0x0020
+	RequiresStaticPhase RequiresAccess = 0x0040
+	RequiresSynthetic   RequiresAccess = 0x1000
+	RequiresMandated    RequiresAccess = 0x8000
+)
+
+func (a RequiresAccess) IsTransitive() bool {
+	return a&RequiresTransitive != 0
+}
+
+func (a RequiresAccess) IsStaticPhase() bool {
+	return a&RequiresStaticPhase != 0
+}
+
+func (a RequiresAccess) IsSynthetic() bool {
+	return a&RequiresSynthetic != 0
+}
+
+func (a RequiresAccess) IsMandated() bool {
+	return a&RequiresMandated != 0
+}
+
+// String returns the modifiers of the requires directive as they would appear
+// in Java source, such as "transitive static".
+func (a RequiresAccess) String() string {
+	return keywords(a, []keyword[RequiresAccess]{
+		{RequiresTransitive, "transitive"},
+		{RequiresStaticPhase, "static"},
+	})
+}
+
+type keyword[T ~uint16] struct {
+	Flag T
+	Word string
+}
+
+func keywords[T ~uint16](a T, table []keyword[T]) string {
+	var words []string
+	for _, k := range table {
+		if a&k.Flag != 0 {
+			words = append(words, k.Word)
+		}
+	}
+	return strings.Join(words, " ")
+}
+
+func oneVisibility(flags uint16) bool {
+	v := flags & 0x0007
+	return v&(v-1) == 0
+}
+
+//Errors
+
+var (
+	ErrConflictingVisibility  = errors.New("more than one of public, private and protected")
+	ErrAbstractFinal          = errors.New("both abstract and final")
+	ErrInterfaceNotAbstract   = errors.New("interface is not abstract")
+	ErrInvalidInterfaceFlags  = errors.New("invalid flags for interface")
+	ErrAnnotationNotInterface = errors.New("annotation is not an interface")
+	ErrInvalidModuleFlags     = errors.New("invalid flags for module")
+	ErrFinalVolatile          = errors.New("both final and volatile")
+	ErrInvalidInterfaceMember = errors.New("invalid flags for interface member")
+	ErrInvalidAbstractMethod  = errors.New("invalid flags for abstract method")
+)
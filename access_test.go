@@ -0,0 +1,84 @@
+package javaclass
+
+import "testing"
+
+func TestAccessString(t *testing.T) {
+	for n, test := range [...]struct {
+		Flags    interface{ String() string }
+		Expected string
+	}{
+		{ClassPublic | ClassSuper, "public class"},
+		{ClassPublic | ClassFinal | ClassSuper, "public final class"},
+		{ClassAbstract | ClassSuper, "abstract class"},
+		{ClassPublic | ClassInterface | ClassAbstract, "public interface"},
+		{ClassPublic | ClassInterface | ClassAbstract | ClassAnnotation, "public @interface"},
+		{ClassPublic | ClassFinal | ClassSuper | ClassEnum, "public enum"},
+		{ClassModule, "module"},
+		{InnerClassPrivate | InnerClassStatic | InnerClassFinal, "private static final class"},
+		{InnerClassProtected | InnerClassStatic | InnerClassInterface | InnerClassAbstract, "protected static interface"},
+		{FieldPrivate | FieldStatic | FieldFinal | FieldSynthetic, "private static final"},
+		{FieldProtected | FieldVolatile | FieldTransient, "protected transient volatile"},
+		{FieldAccess(0), ""},
+		{MethodPublic | MethodStatic | MethodSynchronized | MethodVarargs, "public static synchronized"},
+		{MethodProtected | MethodAbstract, "protected abstract"},
+		{MethodPrivate | MethodFinal | MethodNative | MethodStrict | MethodBridge, "private final native strictfp"},
+		{ParameterFinal | ParameterMandated, "final"},
+		{ModuleOpen | ModuleSynthetic, "open"},
+		{RequiresTransitive | RequiresStaticPhase, "transitive static"},
+	} {
+		if s := test.Flags.String(); s != test.Expected {
+			t.Errorf("test %d: expecting %q, got %q", n+1, test.Expected, s)
+		}
+	}
+}
+
+func TestAccessPredicates(t *testing.T) {
+	m := MethodPublic | MethodBridge | MethodSynthetic
+	if !m.IsPublic() || !m.IsBridge() || !m.IsSynthetic() || m.IsStatic() || m.IsVarargs() {
+		t.Errorf("unexpected predicates for %#04x", uint16(m))
+	}
+	if f := FieldStatic | FieldEnum; !f.IsStatic() || !f.IsEnum() || f.IsFinal() {
+		t.Errorf("unexpected predicates for %#04x", uint16(f))
+	}
+	if r := RequiresStaticPhase; !r.IsStaticPhase() || r.IsTransitive() {
+		t.Errorf("unexpected predicates for %#04x", uint16(r))
+	}
+}
+
+func TestAccessValidate(t *testing.T) {
+	const (
+		class = ClassPublic | ClassSuper
+		iface = ClassPublic | ClassInterface | ClassAbstract
+	)
+	for n, test := range [...]struct {
+		Err, Expected error
+	}{
+		{class.Validate(), nil},
+		{iface.Validate(), nil},
+		{(ClassModule).Validate(), nil},
+		{(ClassModule | ClassPublic).Validate(), ErrInvalidModuleFlags},
+		{(ClassInterface).Validate(), ErrInterfaceNotAbstract},
+		{(iface | ClassFinal).Validate(), ErrInvalidInterfaceFlags},
+		{(class | ClassAnnotation).Validate(), ErrAnnotationNotInterface},
+		{(class | ClassAbstract | ClassFinal).Validate(), ErrAbstractFinal},
+		{(FieldPrivate | FieldFinal).Validate(class), nil},
+		{(FieldPublic | FieldPrivate).Validate(class), ErrConflictingVisibility},
+		{(FieldFinal | FieldVolatile).Validate(class), ErrFinalVolatile},
+		{(FieldPublic | FieldStatic | FieldFinal).Validate(iface), nil},
+		{(FieldPublic | FieldStatic).Validate(iface), ErrInvalidInterfaceMember},
+		{(MethodPublic | MethodAbstract).Validate(class), nil},
+		{(MethodProtected | MethodPrivate).Validate(class), ErrConflictingVisibility},
+		{(MethodPrivate | MethodAbstract).Validate(class), ErrInvalidAbstractMethod},
+		{(MethodStatic | MethodAbstract).Validate(class), ErrInvalidAbstractMethod},
+		{(MethodPrivate | MethodStatic).Validate(iface), nil},
+		{(MethodAbstract).Validate(iface), ErrInvalidInterfaceMember},
+		{(MethodPublic | MethodSynchronized).Validate(iface), ErrInvalidInterfaceMember},
+		{(InnerClassPrivate | InnerClassStatic).Validate(), nil},
+		{(InnerClassPublic | InnerClassProtected).Validate(), ErrConflictingVisibility},
+		{(InnerClassStatic | InnerClassInterface).Validate(), ErrInterfaceNotAbstract},
+	} {
+		if test.Err != test.Expected {
+			t.Errorf("test %d: expecting error %v, got %v", n+1, test.Expected, test.Err)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package javaclass
+
+import "errors"
+
+// AnnotationElement is the value of an element of an annotation, together with
+// the class whose constant pool the indices in the value refer to.
+type AnnotationElement struct {
+	Value ElementValue
+	Class *Class
+}
+
+// AnnotationDefaults returns the default value of every element of the
+// annotation interface c, as given by the AnnotationDefault attributes of its
+// methods, keyed by element name.
+func (c *Class) AnnotationDefaults() (map[string]ElementValue, error) {
+	defaults := make(map[string]ElementValue)
+	for _, method := range c.Methods {
+		for _, attribute := range method.Attributes {
+			if ad, ok := attribute.(AnnotationDefaultAttribute); ok {
+				name, err := c.UTF8(method.NameIndex)
+				if err != nil {
+					return nil, err
+				}
+				defaults[name] = ad.DefaultValue
+			}
+		}
+	}
+	return defaults, nil
+}
+
+// AnnotationValues returns the effective value of every element of the
+// annotation a, which was read from c, keyed by element name.
+//
+// Elements not given a value in a take their default from annotationType,
+// which, when not nil, must be the class of the annotation interface. The
+// Class field of each AnnotationElement is the class whose constant pool the
+// indices in its Value refer to.
+func (c *Class) AnnotationValues(a Annotation, annotationType *Class) (map[string]AnnotationElement, error) {
+	values := make(map[string]AnnotationElement)
+	if annotationType != nil {
+		typeName, err := c.UTF8(a.TypeIndex)
+		if err != nil {
+			return nil, err
+		}
+		className, err := annotationType.ClassName(annotationType.ThisClass)
+		if err != nil {
+			return nil, err
+		}
+		if typeName != "L"+className+";" {
+			return nil, ErrAnnotationTypeMismatch
+		}
+		defaults, err := annotationType.AnnotationDefaults()
+		if err != nil {
+			return nil, err
+		}
+		for name, value := range defaults {
+			values[name] = AnnotationElement{
+				Value: value,
+				Class: annotationType,
+			}
+		}
+	}
+	for _, pair := range a.ElementValuePairs {
+		name, err := c.UTF8(pair.ElementNameIndex)
+		if err != nil {
+			return nil, err
+		}
+		values[name] = AnnotationElement{
+			Value: pair.Value,
+			Class: c,
+		}
+	}
+	return values, nil
+}
+
+// AnnotationValue returns the effective value of the named element of the
+// annotation a, as given by AnnotationValues, and whether the element has a
+// value.
+func (c *Class) AnnotationValue(a Annotation, annotationType *Class, name string) (AnnotationElement, bool, error) {
+	values, err := c.AnnotationValues(a, annotationType)
+	if err != nil {
+		return AnnotationElement{}, false, err
+	}
+	value, ok := values[name]
+	return value, ok, nil
+}
+
+//Errors
+
+var ErrAnnotationTypeMismatch = errors.New("annotation type does not match annotation interface")
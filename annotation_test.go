@@ -0,0 +1,173 @@
+package javaclass
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+var annotationElementNames = []string{"b", "c", "d", "f", "i", "j", "s", "z", "str", "e", "cls", "ann", "arr"}
+
+// annotationInterface returns an annotation interface, Ann, with an element
+// defaulting to a value of each element value tag.
+func annotationInterface() []byte {
+	values := [][]byte{
+		constElementValue(EVByte, 7),
+		constElementValue(EVChar, 7),
+		constElementValue(EVDouble, 8),
+		constElementValue(EVFloat, 10),
+		constElementValue(EVInt, 7),
+		constElementValue(EVLong, 11),
+		constElementValue(EVShort, 7),
+		constElementValue(EVBoolean, 7),
+		constElementValue(EVString, 13),
+		cat(u1(EVEnumConstant), u2(14, 15)),
+		cat(u1(EVClass), u2(14)),
+		cat(u1(EVAnnotationType), u2(16), list2(cat(u2(21), constElementValue(EVInt, 7)))),
+		cat(u1(EVArray), list2(constElementValue(EVInt, 7), cat(u1(EVEnumConstant), u2(14, 15)))),
+	}
+	pool := [][]byte{
+		utf8Constant("Ann"),
+		cat(u1(ConstantClass), u2(1)),
+		utf8Constant("java/lang/Object"),
+		cat(u1(ConstantClass), u2(3)),
+		utf8Constant(AttrAnnotationDefault),
+		utf8Constant("()I"),
+		cat(u1(ConstantInteger), u4(1)),
+		cat(u1(ConstantDouble), u4(0, 0)),
+		cat(u1(ConstantFloat), u4(0)),
+		cat(u1(ConstantLong), u4(0, 1)),
+		utf8Constant("v"),
+		utf8Constant("LE;"),
+		utf8Constant("A"),
+		utf8Constant("LAnn;"),
+	}
+	methods := make([][]byte, len(values))
+	for n, name := range annotationElementNames {
+		pool = append(pool, utf8Constant(name))
+		body := values[n]
+		methods[n] = cat(u2(0x0401, 17+n, 6), list2(cat(u2(5), u4(len(body)), body)))
+	}
+	return cat(
+		u4(Magic),
+		u2(0, 52),
+		u2(17+len(annotationElementNames)), cat(pool...),
+		u2(0x2601, 2, 4),
+		list2(u2(4)),
+		list2(),
+		list2(methods...),
+		list2(),
+	)
+}
+
+func TestAnnotationDefaults(t *testing.T) {
+	annotationType, err := Parse(annotationInterface())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defaults, err := annotationType.AnnotationDefaults()
+	if err != nil {
+		t.Fatal(err)
+	}
+	enum := EnumConstValue{TypeNameIndex: 14, ConstNameIndex: 15}
+	expected := map[string]ElementValue{
+		"b":   ConstValueIndex{tag: EVByte, Index: 7},
+		"c":   ConstValueIndex{tag: EVChar, Index: 7},
+		"d":   ConstValueIndex{tag: EVDouble, Index: 8},
+		"f":   ConstValueIndex{tag: EVFloat, Index: 10},
+		"i":   ConstValueIndex{tag: EVInt, Index: 7},
+		"j":   ConstValueIndex{tag: EVLong, Index: 11},
+		"s":   ConstValueIndex{tag: EVShort, Index: 7},
+		"z":   ConstValueIndex{tag: EVBoolean, Index: 7},
+		"str": ConstValueIndex{tag: EVString, Index: 13},
+		"e":   enum,
+		"cls": ClassInfoIndex{Index: 14},
+		"ann": AnnotationValue{Annotation{
+			TypeIndex:         16,
+			ElementValuePairs: []ElementValuePair{{ElementNameIndex: 21, Value: ConstValueIndex{tag: EVInt, Index: 7}}},
+		}},
+		"arr": ArrayValue{[]ElementValue{ConstValueIndex{tag: EVInt, Index: 7}, enum}},
+	}
+	if !reflect.DeepEqual(defaults, expected) {
+		t.Errorf("expecting defaults %v, got %v", expected, defaults)
+	}
+	tags := []uint8{EVByte, EVChar, EVDouble, EVFloat, EVInt, EVLong, EVShort, EVBoolean, EVString, EVEnumConstant, EVClass, EVAnnotationType, EVArray}
+	for n, name := range annotationElementNames {
+		if tag := defaults[name].Tag(); tag != tags[n] {
+			t.Errorf("test %d: expecting tag %c for %s, got %c", n+1, tags[n], name, tag)
+		}
+	}
+	annotationType.Methods[0].NameIndex = 0
+	if _, err := annotationType.AnnotationDefaults(); !errors.Is(err, ErrInvalidConstantPoolIndex) {
+		t.Errorf("expecting error %v, got %v", ErrInvalidConstantPoolIndex, err)
+	}
+}
+
+func TestAnnotationValues(t *testing.T) {
+	annotationType, err := Parse(annotationInterface())
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := &Class{
+		ConstantPool: []CPInfo{
+			nil,
+			ConstantUTF8Info{String: "LAnn;"},
+			ConstantUTF8Info{String: "i"},
+			ConstantIntegerInfo{5},
+			ConstantUTF8Info{String: "x"},
+			ConstantUTF8Info{String: "LOther;"},
+		},
+	}
+	a := Annotation{
+		TypeIndex: 1,
+		ElementValuePairs: []ElementValuePair{
+			{ElementNameIndex: 2, Value: ConstValueIndex{tag: EVInt, Index: 3}},
+			{ElementNameIndex: 4, Value: ClassInfoIndex{Index: 1}},
+		},
+	}
+	values, err := c.AnnotationValues(a, annotationType)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(values) != len(annotationElementNames)+1 {
+		t.Errorf("expecting %d values, got %d", len(annotationElementNames)+1, len(values))
+	}
+	for n, test := range [...]struct {
+		Name  string
+		Value ElementValue
+		Class *Class
+	}{
+		{"i", ConstValueIndex{tag: EVInt, Index: 3}, c},
+		{"x", ClassInfoIndex{Index: 1}, c},
+		{"j", ConstValueIndex{tag: EVLong, Index: 11}, annotationType},
+		{"cls", ClassInfoIndex{Index: 14}, annotationType},
+	} {
+		if v := values[test.Name]; !reflect.DeepEqual(v.Value, test.Value) || v.Class != test.Class {
+			t.Errorf("test %d: unexpected value for %s: %v", n+1, test.Name, v)
+		}
+		if v, ok, err := c.AnnotationValue(a, annotationType, test.Name); err != nil || !ok || !reflect.DeepEqual(v, values[test.Name]) {
+			t.Errorf("test %d: expecting value %v for %s, got %v, %t (%v)", n+1, values[test.Name], test.Name, v, ok, err)
+		}
+	}
+	if values, err := c.AnnotationValues(a, nil); err != nil || len(values) != 2 {
+		t.Errorf("expecting only explicit values without annotation type, got %v (%v)", values, err)
+	}
+	if _, ok, err := c.AnnotationValue(a, nil, "j"); err != nil || ok {
+		t.Errorf("expecting no value for j without annotation type, got %t (%v)", ok, err)
+	}
+	a.TypeIndex = 5
+	if _, err := c.AnnotationValues(a, annotationType); err != ErrAnnotationTypeMismatch {
+		t.Errorf("expecting error %v, got %v", ErrAnnotationTypeMismatch, err)
+	}
+}
+
+func TestUnknownElementValueTag(t *testing.T) {
+	data := annotationInterface()
+	i := len(data) - 2 - 11 // the default of arr precedes the class attributes
+	if data[i] != EVArray {
+		t.Fatalf("expecting array tag at %d, got %c", i, data[i])
+	}
+	data[i] = 'X'
+	if _, err := Parse(data); !errors.Is(err, ErrUnknownElementValueTag) {
+		t.Errorf("expecting error %v, got %v", ErrUnknownElementValueTag, err)
+	}
+}
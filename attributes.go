@@ -1,11 +1,13 @@
 package javaclass
 
 import (
+	"bytes"
 	"errors"
 	"io"
-	"io/ioutil"
+	"math"
 
 	"vimagination.zapto.org/byteio"
+	"vimagination.zapto.org/javaclass/bytecode"
 )
 
 const (
@@ -27,122 +29,398 @@ const (
 	AttrRuntimeInvisibleAnnotations          = "RuntimeInvisibleAnnotations"
 	AttrRuntimeVisibleParameterAnnotations   = "RuntimeVisibleParameterAnnotations"
 	AttrRuntimeInvisibleParameterAnnotations = "RuntimeInvisibleParameterAnnotations"
+	AttrRuntimeVisibleTypeAnnotations        = "RuntimeVisibleTypeAnnotations"
+	AttrRuntimeInvisibleTypeAnnotations      = "RuntimeInvisibleTypeAnnotations"
 	AttrAnnotationDefault                    = "AnnotationDefault"
 	AttrBootstrapMethods                     = "BootstrapMethods"
+	AttrModule                               = "Module"
+	AttrModulePackages                       = "ModulePackages"
+	AttrModuleMainClass                      = "ModuleMainClass"
+	AttrNestHost                             = "NestHost"
+	AttrNestMembers                          = "NestMembers"
+	AttrRecord                               = "Record"
+	AttrPermittedSubclasses                  = "PermittedSubclasses"
+	AttrMethodParameters                     = "MethodParameters"
 )
 
+type attributeLocation uint8
+
+const (
+	locationClass attributeLocation = 1 << iota
+	locationField
+	locationMethod
+	locationCode
+	locationRecordComponent
+)
+
+var attributeLocations = map[string]attributeLocation{
+	AttrConstantValue:                        locationField,
+	AttrCode:                                 locationMethod,
+	AttrStackMapTable:                        locationCode,
+	AttrExceptions:                           locationMethod,
+	AttrInnerClasses:                         locationClass,
+	AttrEnclosingMethod:                      locationClass,
+	AttrSynthetic:                            locationClass | locationField | locationMethod,
+	AttrSignature:                            locationClass | locationField | locationMethod | locationRecordComponent,
+	AttrSourceFile:                           locationClass,
+	AttrSourceDebugExtension:                 locationClass,
+	AttrLineNumberTable:                      locationCode,
+	AttrLocalVariableTable:                   locationCode,
+	AttrLocalVariableTypeTable:               locationCode,
+	AttrDeprecated:                           locationClass | locationField | locationMethod,
+	AttrRuntimeVisibleAnnotations:            locationClass | locationField | locationMethod | locationRecordComponent,
+	AttrRuntimeInvisibleAnnotations:          locationClass | locationField | locationMethod | locationRecordComponent,
+	AttrRuntimeVisibleParameterAnnotations:   locationMethod,
+	AttrRuntimeInvisibleParameterAnnotations: locationMethod,
+	AttrRuntimeVisibleTypeAnnotations:        locationClass | locationField | locationMethod | locationCode | locationRecordComponent,
+	AttrRuntimeInvisibleTypeAnnotations:      locationClass | locationField | locationMethod | locationCode | locationRecordComponent,
+	AttrAnnotationDefault:                    locationMethod,
+	AttrBootstrapMethods:                     locationClass,
+	AttrModule:                               locationClass,
+	AttrModulePackages:                       locationClass,
+	AttrModuleMainClass:                      locationClass,
+	AttrNestHost:                             locationClass,
+	AttrNestMembers:                          locationClass,
+	AttrRecord:                               locationClass,
+	AttrPermittedSubclasses:                  locationClass,
+	AttrMethodParameters:                     locationMethod,
+}
+
+var repeatableAttributes = map[string]bool{
+	AttrSynthetic:              true,
+	AttrDeprecated:             true,
+	AttrLineNumberTable:        true,
+	AttrLocalVariableTable:     true,
+	AttrLocalVariableTypeTable: true,
+}
+
 type AttributeInfo interface {
 	Name() string
 }
 
-func (c *Class) readAttributes(r io.Reader) ([]AttributeInfo, error) {
-	br := byteio.BigEndianReader{Reader: r}
+// AttributeNameIndex is embedded in each attribute type to record the constant
+// pool index of the name of the attribute.
+//
+// It is set when an attribute is read, so that WriteTo reproduces the original
+// index when the constant pool holds the name more than once. When it is zero,
+// or does not refer to a CONSTANT_Utf8 entry holding the name, the first such
+// entry is used instead.
+type AttributeNameIndex uint16
+
+func (a AttributeNameIndex) nameIndex() uint16 {
+	return uint16(a)
+}
+
+// readAttributes reads an attributes table found at the given location.
+func (d *decoder) readAttributes(r io.Reader, location attributeLocation) ([]AttributeInfo, error) {
+	br := binaryReader{r}
 	attributesCount, _, err := br.ReadUint16()
 	if err != nil {
 		return nil, err
 	}
+	if err := reserve[AttributeInfo](d, r, int(attributesCount), 6); err != nil {
+		return nil, err
+	}
 	attributes := make([]AttributeInfo, 0, attributesCount)
-	for i := uint16(0); i < attributesCount; i++ {
+	if err := d.scanAttributes(r, attributesCount, location, func(name string, nameIndex AttributeNameIndex, permitted bool, lr *io.LimitedReader) error {
+		attributeInfo, err := d.readAttribute(name, nameIndex, permitted, lr)
+		if err != nil {
+			return err
+		}
+		attributes = append(attributes, attributeInfo)
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return attributes, nil
+}
+
+// scanAttributes calls fn with the body of each of the count attributes that
+// follow, and checks that fn consumes exactly the length of the attribute.
+//
+// Predefined attributes that are not permitted at the location are passed with
+// permitted set to false, and should be read as UnknownAttribute as they
+// would be ignored by the JVM, while predefined attributes that may only
+// appear once in a table are rejected if repeated.
+func (d *decoder) scanAttributes(r io.Reader, count uint16, location attributeLocation, fn func(name string, nameIndex AttributeNameIndex, permitted bool, lr *io.LimitedReader) error) error {
+	br := binaryReader{r}
+	var seen map[string]bool
+	for i := uint16(0); i < count; i++ {
 		ani, _, err := br.ReadUint16()
 		if err != nil {
-			return nil, err
+			return indexError(err, "attributes", int(i))
 		}
-		if int(ani) >= len(c.ConstantPool) {
-			return nil, ErrInvalidConstantPoolIndex
+		if int(ani) >= len(d.ConstantPool) {
+			return indexError(valueError(ErrInvalidConstantPoolIndex, int64(ani)), "attributes", int(i))
 		}
-		cpi := c.ConstantPool[ani]
+		cpi := d.ConstantPool[ani]
 		if cpi.Type() != ConstantUTF8 {
-			return nil, ErrInvalidConstantPoolType
+			return indexError(valueError(ErrInvalidConstantPoolType, int64(ani)), "attributes", int(i))
 		}
 		cpUTF, ok := cpi.(ConstantUTF8Info)
 		if !ok {
-			return nil, ErrInvalidConstantPoolType
+			return indexError(valueError(ErrInvalidConstantPoolType, int64(ani)), "attributes", int(i))
 		}
+		name := cpUTF.String
 		attributeLength, _, err := br.ReadUint32()
 		if err != nil {
-			return nil, err
+			return pathError(err, name)
+		}
+		lr := &io.LimitedReader{R: r, N: int64(attributeLength)}
+		permitted := true
+		if locations, ok := attributeLocations[name]; ok {
+			if locations&location == 0 {
+				permitted = false
+			} else if !repeatableAttributes[name] {
+				if seen[name] {
+					return pathError(ErrDuplicateAttribute, name)
+				} else if seen == nil {
+					seen = make(map[string]bool)
+				}
+				seen[name] = true
+			}
+		}
+		if permitted && d.skipAttribute(name) {
+			if err := discard(lr); err != nil {
+				return pathError(err, name)
+			}
+			continue
+		}
+		err = fn(name, AttributeNameIndex(ani), permitted, lr)
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			if lr.N == 0 {
+				err = ErrAttributeLength
+			}
+		} else if err == nil && lr.N != 0 {
+			err = ErrAttributeLength
+		}
+		if err != nil {
+			return pathError(err, name)
+		}
+	}
+	return nil
+}
+
+func (d *decoder) readAttribute(name string, nameIndex AttributeNameIndex, permitted bool, lr *io.LimitedReader) (AttributeInfo, error) {
+	if !permitted {
+		return d.readUnknownAttribute(name, nameIndex, lr)
+	}
+	switch name {
+	case AttrConstantValue:
+		return readConstantValue(lr, nameIndex)
+	case AttrCode:
+		return d.readCode(lr, nameIndex)
+	case AttrStackMapTable:
+		return d.readStackMapTable(lr, nameIndex)
+	case AttrExceptions:
+		return d.readExceptions(lr, nameIndex)
+	case AttrInnerClasses:
+		return d.readInnerClasses(lr, nameIndex)
+	case AttrEnclosingMethod:
+		return readEnclosingMethod(lr, nameIndex)
+	case AttrSynthetic:
+		return readSynthetic(lr, nameIndex)
+	case AttrSignature:
+		return readSignature(lr, nameIndex)
+	case AttrSourceFile:
+		return readSourceFile(lr, nameIndex)
+	case AttrSourceDebugExtension:
+		return d.readSourceDebugExtension(lr, nameIndex)
+	case AttrLineNumberTable:
+		return d.readLineNumberTable(lr, nameIndex)
+	case AttrLocalVariableTable:
+		return d.readLocalVariableTable(lr, nameIndex)
+	case AttrLocalVariableTypeTable:
+		return d.readLocalVariableTypeTable(lr, nameIndex)
+	case AttrDeprecated:
+		return readDeprecated(lr, nameIndex)
+	case AttrRuntimeVisibleAnnotations:
+		return d.readRuntimeVisibleAnnotations(lr, nameIndex)
+	case AttrRuntimeInvisibleAnnotations:
+		return d.readRuntimeInvisibleAnnotations(lr, nameIndex)
+	case AttrRuntimeVisibleParameterAnnotations:
+		return d.readRuntimeVisibleParameterAnnotations(lr, nameIndex)
+	case AttrRuntimeInvisibleParameterAnnotations:
+		return d.readRuntimeInvisibleParameterAnnotations(lr, nameIndex)
+	case AttrRuntimeVisibleTypeAnnotations:
+		return d.readRuntimeVisibleTypeAnnotations(lr, nameIndex)
+	case AttrRuntimeInvisibleTypeAnnotations:
+		return d.readRuntimeInvisibleTypeAnnotations(lr, nameIndex)
+	case AttrAnnotationDefault:
+		return d.readAnnotationDefault(lr, nameIndex)
+	case AttrBootstrapMethods:
+		return d.readBootstrapMethods(lr, nameIndex)
+	case AttrModule:
+		return d.readModule(lr, nameIndex)
+	case AttrModulePackages:
+		return d.readModulePackages(lr, nameIndex)
+	case AttrModuleMainClass:
+		return readModuleMainClass(lr, nameIndex)
+	case AttrNestHost:
+		return readNestHost(lr, nameIndex)
+	case AttrNestMembers:
+		return d.readNestMembers(lr, nameIndex)
+	case AttrRecord:
+		return d.readRecord(lr, nameIndex)
+	case AttrPermittedSubclasses:
+		return d.readPermittedSubclasses(lr, nameIndex)
+	case AttrMethodParameters:
+		return d.readMethodParameters(lr, nameIndex)
+	}
+	return d.readCustomAttribute(name, nameIndex, lr)
+}
+
+func (e *encoder) writeAttributes(w io.Writer, attributes []AttributeInfo) error {
+	if err := writeLength(w, len(attributes)); err != nil {
+		return err
+	}
+	bw := byteio.BigEndianWriter{Writer: w}
+	var buf bytes.Buffer
+	for _, attributeInfo := range attributes {
+		if attributeInfo == nil {
+			return ErrUnknownAttributeType
 		}
-		lr := io.LimitReader(r, int64(attributeLength))
-		var attributeInfo AttributeInfo
-		switch cpUTF.String {
-		case AttrConstantValue:
-			attributeInfo, err = readConstantValue(lr)
-		case AttrCode:
-			attributeInfo, err = c.readCode(lr)
-		case AttrStackMapTable:
-			attributeInfo, err = readStackMapTable(lr)
-		case AttrExceptions:
-			attributeInfo, err = readExceptions(lr)
-		case AttrInnerClasses:
-			attributeInfo, err = readInnerClasses(lr)
-		case AttrEnclosingMethod:
-			attributeInfo, err = readEnclosingMethod(lr)
-		case AttrSynthetic:
-			attributeInfo, err = readSynthetic(lr)
-		case AttrSignature:
-			attributeInfo, err = readSignature(lr)
-		case AttrSourceFile:
-			attributeInfo, err = readSourceFile(lr)
-		case AttrSourceDebugExtension:
-			attributeInfo, err = readSourceDebugExtension(lr)
-		case AttrLineNumberTable:
-			attributeInfo, err = readLineNumberTable(lr)
-		case AttrLocalVariableTable:
-			attributeInfo, err = readLocalVariableTable(lr)
-		case AttrLocalVariableTypeTable:
-			attributeInfo, err = readLocalVariableTypeTable(lr)
-		case AttrDeprecated:
-			attributeInfo, err = readDeprecated(lr)
-		case AttrRuntimeVisibleAnnotations:
-			attributeInfo, err = readRuntimeVisibleAnnotations(lr)
-		case AttrRuntimeInvisibleAnnotations:
-			attributeInfo, err = readRuntimeInvisibleAnnotations(lr)
-		case AttrRuntimeVisibleParameterAnnotations:
-			attributeInfo, err = readRuntimeVisibleParameterAnnotations(lr)
-		case AttrRuntimeInvisibleParameterAnnotations:
-			attributeInfo, err = readRuntimeInvisibleParameterAnnotations(lr)
-		case AttrAnnotationDefault:
-			attributeInfo, err = readAnnotationDefault(lr)
-		case AttrBootstrapMethods:
-			attributeInfo, err = readBootstrapMethods(lr)
+		ani, ok := e.nameIndex(attributeInfo)
+		if !ok {
+			return ErrMissingAttributeName
+		}
+		buf.Reset()
+		var err error
+		switch a := attributeInfo.(type) {
+		case ConstantValueAttribute:
+			err = writeConstantValue(&buf, a)
+		case CodeAttribute:
+			err = e.writeCode(&buf, a)
+		case StackMapTableAttribute:
+			err = writeStackMapTable(&buf, a)
+		case ExceptionsAttribute:
+			err = writeExceptions(&buf, a)
+		case InnerClassesAttribute:
+			err = writeInnerClasses(&buf, a)
+		case EnclosingMethodAttribute:
+			err = writeEnclosingMethod(&buf, a)
+		case SyntheticAttribute:
+		case SignatureAttribute:
+			err = writeSignature(&buf, a)
+		case SourceFileAttribute:
+			err = writeSourceFile(&buf, a)
+		case SourceDebugAttribute:
+			_, err = buf.WriteString(a.DebugExtension)
+		case LineNumberTableAttribute:
+			err = writeLineNumberTable(&buf, a)
+		case LocalVariableTableAttribute:
+			err = writeLocalVariableTable(&buf, a)
+		case LocalVariableTypeTableAttribute:
+			err = writeLocalVariableTypeTable(&buf, a)
+		case DeprecatedAttribute:
+		case RuntimeVisibleAnnotationsAttribute:
+			err = writeAnnotations(&buf, a.Annotations)
+		case RuntimeInvisibleAnnotationsAttribute:
+			err = writeAnnotations(&buf, a.Annotations)
+		case RuntimeVisibleParameterAnnotationsAttribute:
+			err = writeParameterAnnotations(&buf, a.ParameterAnnotations)
+		case RuntimeInvisibleParameterAnnotationsAttribute:
+			err = writeParameterAnnotations(&buf, a.ParameterAnnotations)
+		case RuntimeVisibleTypeAnnotationsAttribute:
+			err = writeTypeAnnotations(&buf, a.Annotations)
+		case RuntimeInvisibleTypeAnnotationsAttribute:
+			err = writeTypeAnnotations(&buf, a.Annotations)
+		case AnnotationDefaultAttribute:
+			err = writeElementValue(&buf, a.DefaultValue)
+		case BootstrapMethodsAttribute:
+			err = writeBootstrapMethods(&buf, a)
+		case ModuleAttribute:
+			err = writeModule(&buf, a)
+		case ModulePackagesAttribute:
+			err = writeIndexTable(&buf, a.PackageIndex)
+		case ModuleMainClassAttribute:
+			err = writeModuleMainClass(&buf, a)
+		case NestHostAttribute:
+			err = writeNestHost(&buf, a)
+		case NestMembersAttribute:
+			err = writeIndexTable(&buf, a.Classes)
+		case RecordAttribute:
+			err = e.writeRecord(&buf, a)
+		case PermittedSubclassesAttribute:
+			err = writeIndexTable(&buf, a.Classes)
+		case MethodParametersAttribute:
+			err = writeMethodParameters(&buf, a)
+		case UnknownAttribute:
+			_, err = buf.Write(a.Data)
 		default:
-			return nil, ErrInvalidAttributeName
+			err = e.writeCustomAttribute(&buf, a)
 		}
 		if err != nil {
-			return nil, err
+			return err
+		}
+		if int64(buf.Len()) > math.MaxUint32 {
+			return ErrTooLarge
+		}
+		if _, err = bw.WriteUint16(ani); err != nil {
+			return err
+		}
+		if _, err = bw.WriteUint32(uint32(buf.Len())); err != nil {
+			return err
+		}
+		if _, err = buf.WriteTo(w); err != nil {
+			return err
 		}
-		attributes = append(attributes, attributeInfo)
 	}
-	return attributes, nil
+	return nil
+}
+
+// nameIndex returns the index of the constant pool entry naming the attribute,
+// preferring the index it was read with.
+func (e *encoder) nameIndex(a AttributeInfo) (uint16, bool) {
+	name := a.Name()
+	if n, ok := a.(interface{ nameIndex() uint16 }); ok {
+		if ani := n.nameIndex(); int(ani) < len(e.ConstantPool) {
+			if u, ok := e.ConstantPool[ani].(ConstantUTF8Info); ok && u.String == name {
+				return ani, true
+			}
+		}
+	}
+	ani, ok := e.names[name]
+	return ani, ok
 }
 
 type ConstantValueAttribute struct {
+	AttributeNameIndex
 	ConstantValue uint16
 }
 
-func readConstantValue(r io.Reader) (AttributeInfo, error) {
-	br := byteio.BigEndianReader{Reader: r}
+func readConstantValue(r io.Reader, nameIndex AttributeNameIndex) (AttributeInfo, error) {
+	br := binaryReader{r}
 	n, _, err := br.ReadUint16()
 	if err != nil {
 		return nil, err
 	}
-	return ConstantValueAttribute{n}, nil
+	return ConstantValueAttribute{nameIndex, n}, nil
 }
 
 func (ConstantValueAttribute) Name() string {
 	return AttrConstantValue
 }
 
+func writeConstantValue(w io.Writer, c ConstantValueAttribute) error {
+	bw := byteio.BigEndianWriter{Writer: w}
+	_, err := bw.WriteUint16(c.ConstantValue)
+	return err
+}
+
 type Exception struct {
 	StartPC, EndPC, HandlerPC, CatchType uint16
 }
 
-func readExceptionsTable(r io.Reader) ([]Exception, error) {
-	br := byteio.BigEndianReader{Reader: r}
+func (d *decoder) readExceptionsTable(r io.Reader) ([]Exception, error) {
+	br := binaryReader{r}
 	exceptionTableLength, _, err := br.ReadUint16()
 	if err != nil {
 		return nil, err
 	}
+	if err := reserve[Exception](d, r, int(exceptionTableLength), 8); err != nil {
+		return nil, err
+	}
 	exceptionsTable := make([]Exception, exceptionTableLength)
 	for i := uint16(0); i < exceptionTableLength; i++ {
 		startPC, _, err := br.ReadUint16()
@@ -171,15 +449,38 @@ func readExceptionsTable(r io.Reader) ([]Exception, error) {
 	return exceptionsTable, nil
 }
 
+func writeExceptionsTable(w io.Writer, exceptionsTable []Exception) error {
+	if err := writeLength(w, len(exceptionsTable)); err != nil {
+		return err
+	}
+	bw := byteio.BigEndianWriter{Writer: w}
+	for _, e := range exceptionsTable {
+		if _, err := bw.WriteUint16(e.StartPC); err != nil {
+			return err
+		}
+		if _, err := bw.WriteUint16(e.EndPC); err != nil {
+			return err
+		}
+		if _, err := bw.WriteUint16(e.HandlerPC); err != nil {
+			return err
+		}
+		if _, err := bw.WriteUint16(e.CatchType); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 type CodeAttribute struct {
+	AttributeNameIndex
 	MaxStack, MaxLocals uint16
 	Code                []byte
 	ExceptionTable      []Exception
 	Attributes          []AttributeInfo
 }
 
-func (c *Class) readCode(r io.Reader) (AttributeInfo, error) {
-	br := byteio.BigEndianReader{Reader: r}
+func (d *decoder) readCode(r io.Reader, nameIndex AttributeNameIndex) (AttributeInfo, error) {
+	br := binaryReader{r}
 	maxStack, _, err := br.ReadUint16()
 	if err != nil {
 		return nil, err
@@ -192,25 +493,28 @@ func (c *Class) readCode(r io.Reader) (AttributeInfo, error) {
 	if err != nil {
 		return nil, err
 	}
-	code := make([]byte, codeLength)
-	_, err = io.ReadFull(r, code)
+	if codeLength > d.maxCodeLength() {
+		return nil, valueError(ErrCodeTooLong, int64(codeLength))
+	}
+	code, err := d.readBytes(r, int(codeLength))
 	if err != nil {
 		return nil, err
 	}
-	exceptions, err := readExceptionsTable(r)
+	exceptions, err := d.readExceptionsTable(r)
 	if err != nil {
-		return nil, err
+		return nil, pathError(err, "exception_table")
 	}
-	attributes, err := c.readAttributes(r)
+	attributes, err := d.readAttributes(r, locationCode)
 	if err != nil {
 		return nil, err
 	}
 	return CodeAttribute{
-		MaxStack:       maxStack,
-		MaxLocals:      maxLocals,
-		Code:           code,
-		ExceptionTable: exceptions,
-		Attributes:     attributes,
+		AttributeNameIndex: nameIndex,
+		MaxStack:           maxStack,
+		MaxLocals:          maxLocals,
+		Code:               code,
+		ExceptionTable:     exceptions,
+		Attributes:         attributes,
 	}, nil
 }
 
@@ -218,40 +522,88 @@ func (CodeAttribute) Name() string {
 	return AttrCode
 }
 
+// Instructions decodes the code array of the attribute.
+func (c CodeAttribute) Instructions() ([]bytecode.Instruction, error) {
+	return bytecode.Decode(c.Code)
+}
+
+func (e *encoder) writeCode(w io.Writer, c CodeAttribute) error {
+	bw := byteio.BigEndianWriter{Writer: w}
+	if _, err := bw.WriteUint16(c.MaxStack); err != nil {
+		return err
+	}
+	if _, err := bw.WriteUint16(c.MaxLocals); err != nil {
+		return err
+	}
+	if int64(len(c.Code)) > math.MaxUint32 {
+		return ErrTooLarge
+	}
+	if _, err := bw.WriteUint32(uint32(len(c.Code))); err != nil {
+		return err
+	}
+	if _, err := w.Write(c.Code); err != nil {
+		return err
+	}
+	if err := writeExceptionsTable(w, c.ExceptionTable); err != nil {
+		return err
+	}
+	return e.writeAttributes(w, c.Attributes)
+}
+
 type StackMapTableAttribute struct {
+	AttributeNameIndex
 	Entries []StackMapFrame
 }
 
-func readStackMapTable(r io.Reader) (AttributeInfo, error) {
-	br := byteio.BigEndianReader{Reader: r}
+func (d *decoder) readStackMapTable(r io.Reader, nameIndex AttributeNameIndex) (AttributeInfo, error) {
+	br := binaryReader{r}
 	numEntries, _, err := br.ReadUint16()
 	if err != nil {
 		return nil, err
 	}
+	if err := reserve[StackMapFrame](d, r, int(numEntries), 1); err != nil {
+		return nil, err
+	}
 	entries := make([]StackMapFrame, numEntries)
 	for i := uint16(0); i < numEntries; i++ {
-		entries[i], err = readStackMapFrame(r)
+		entries[i], err = d.readStackMapFrame(r)
 		if err != nil {
-			return nil, err
+			return nil, indexError(err, "entries", int(i))
 		}
 	}
-	return StackMapTableAttribute{entries}, nil
+	return StackMapTableAttribute{nameIndex, entries}, nil
 }
 
 func (StackMapTableAttribute) Name() string {
 	return AttrStackMapTable
 }
 
+func writeStackMapTable(w io.Writer, s StackMapTableAttribute) error {
+	if err := writeLength(w, len(s.Entries)); err != nil {
+		return err
+	}
+	for _, entry := range s.Entries {
+		if err := writeStackMapFrame(w, entry); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 type ExceptionsAttribute struct {
+	AttributeNameIndex
 	ExceptionIndexTable []uint16
 }
 
-func readExceptions(r io.Reader) (AttributeInfo, error) {
-	br := byteio.BigEndianReader{Reader: r}
+func (d *decoder) readExceptions(r io.Reader, nameIndex AttributeNameIndex) (AttributeInfo, error) {
+	br := binaryReader{r}
 	numExceptions, _, err := br.ReadUint16()
 	if err != nil {
 		return nil, err
 	}
+	if err := reserve[uint16](d, r, int(numExceptions), 2); err != nil {
+		return nil, err
+	}
 	exceptions := make([]uint16, numExceptions)
 	for i := uint16(0); i < numExceptions; i++ {
 		exceptions[i], _, err = br.ReadUint16()
@@ -259,27 +611,77 @@ func readExceptions(r io.Reader) (AttributeInfo, error) {
 			return nil, err
 		}
 	}
-	return ExceptionsAttribute{exceptions}, nil
+	return ExceptionsAttribute{nameIndex, exceptions}, nil
 }
 
 func (ExceptionsAttribute) Name() string {
 	return AttrExceptions
 }
 
+func (d *decoder) readIndexTable(r io.Reader) ([]uint16, error) {
+	br := binaryReader{r}
+	count, _, err := br.ReadUint16()
+	if err != nil {
+		return nil, err
+	}
+	if err := reserve[uint16](d, r, int(count), 2); err != nil {
+		return nil, err
+	}
+	indexes := make([]uint16, count)
+	for i := uint16(0); i < count; i++ {
+		indexes[i], _, err = br.ReadUint16()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return indexes, nil
+}
+
+func writeIndexTable(w io.Writer, indexes []uint16) error {
+	if err := writeLength(w, len(indexes)); err != nil {
+		return err
+	}
+	bw := byteio.BigEndianWriter{Writer: w}
+	for _, index := range indexes {
+		if _, err := bw.WriteUint16(index); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeExceptions(w io.Writer, e ExceptionsAttribute) error {
+	if err := writeLength(w, len(e.ExceptionIndexTable)); err != nil {
+		return err
+	}
+	bw := byteio.BigEndianWriter{Writer: w}
+	for _, exception := range e.ExceptionIndexTable {
+		if _, err := bw.WriteUint16(exception); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 type ClassInfo struct {
-	InnerClassInfoIndex, OuterClassInfoIndex, InnerClassNameIndex, InnerClassAccessFlags uint16
+	InnerClassInfoIndex, OuterClassInfoIndex, InnerClassNameIndex uint16
+	InnerClassAccessFlags                                         InnerClassAccess
 }
 
 type InnerClassesAttribute struct {
+	AttributeNameIndex
 	Classes []ClassInfo
 }
 
-func readInnerClasses(r io.Reader) (AttributeInfo, error) {
-	br := byteio.BigEndianReader{Reader: r}
+func (d *decoder) readInnerClasses(r io.Reader, nameIndex AttributeNameIndex) (AttributeInfo, error) {
+	br := binaryReader{r}
 	numClasses, _, err := br.ReadUint16()
 	if err != nil {
 		return nil, err
 	}
+	if err := reserve[ClassInfo](d, r, int(numClasses), 8); err != nil {
+		return nil, err
+	}
 	classes := make([]ClassInfo, numClasses)
 	for i := uint16(0); i < numClasses; i++ {
 		innerClassInfo, _, err := br.ReadUint16()
@@ -302,22 +704,45 @@ func readInnerClasses(r io.Reader) (AttributeInfo, error) {
 			InnerClassInfoIndex:   innerClassInfo,
 			OuterClassInfoIndex:   outerClassInfo,
 			InnerClassNameIndex:   innerClassName,
-			InnerClassAccessFlags: innerClassFlags,
+			InnerClassAccessFlags: InnerClassAccess(innerClassFlags),
 		}
 	}
-	return InnerClassesAttribute{classes}, nil
+	return InnerClassesAttribute{nameIndex, classes}, nil
 }
 
 func (InnerClassesAttribute) Name() string {
 	return AttrInnerClasses
 }
 
+func writeInnerClasses(w io.Writer, i InnerClassesAttribute) error {
+	if err := writeLength(w, len(i.Classes)); err != nil {
+		return err
+	}
+	bw := byteio.BigEndianWriter{Writer: w}
+	for _, class := range i.Classes {
+		if _, err := bw.WriteUint16(class.InnerClassInfoIndex); err != nil {
+			return err
+		}
+		if _, err := bw.WriteUint16(class.OuterClassInfoIndex); err != nil {
+			return err
+		}
+		if _, err := bw.WriteUint16(class.InnerClassNameIndex); err != nil {
+			return err
+		}
+		if _, err := bw.WriteUint16(uint16(class.InnerClassAccessFlags)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 type EnclosingMethodAttribute struct {
+	AttributeNameIndex
 	ClassIndex, MethodIndex uint16
 }
 
-func readEnclosingMethod(r io.Reader) (AttributeInfo, error) {
-	br := byteio.BigEndianReader{Reader: r}
+func readEnclosingMethod(r io.Reader, nameIndex AttributeNameIndex) (AttributeInfo, error) {
+	br := binaryReader{r}
 	classIndex, _, err := br.ReadUint16()
 	if err != nil {
 		return nil, err
@@ -327,8 +752,9 @@ func readEnclosingMethod(r io.Reader) (AttributeInfo, error) {
 		return nil, err
 	}
 	return EnclosingMethodAttribute{
-		ClassIndex:  classIndex,
-		MethodIndex: methodIndex,
+		AttributeNameIndex: nameIndex,
+		ClassIndex:         classIndex,
+		MethodIndex:        methodIndex,
 	}, nil
 }
 
@@ -336,10 +762,21 @@ func (EnclosingMethodAttribute) Name() string {
 	return AttrEnclosingMethod
 }
 
-type SyntheticAttribute struct{}
+func writeEnclosingMethod(w io.Writer, e EnclosingMethodAttribute) error {
+	bw := byteio.BigEndianWriter{Writer: w}
+	if _, err := bw.WriteUint16(e.ClassIndex); err != nil {
+		return err
+	}
+	_, err := bw.WriteUint16(e.MethodIndex)
+	return err
+}
+
+type SyntheticAttribute struct {
+	AttributeNameIndex
+}
 
-func readSynthetic(r io.Reader) (AttributeInfo, error) {
-	return SyntheticAttribute{}, nil
+func readSynthetic(r io.Reader, nameIndex AttributeNameIndex) (AttributeInfo, error) {
+	return SyntheticAttribute{nameIndex}, nil
 }
 
 func (SyntheticAttribute) Name() string {
@@ -347,49 +784,64 @@ func (SyntheticAttribute) Name() string {
 }
 
 type SignatureAttribute struct {
+	AttributeNameIndex
 	SignatureIndex uint16
 }
 
-func readSignature(r io.Reader) (AttributeInfo, error) {
-	br := byteio.BigEndianReader{Reader: r}
+func readSignature(r io.Reader, nameIndex AttributeNameIndex) (AttributeInfo, error) {
+	br := binaryReader{r}
 	n, _, err := br.ReadUint16()
 	if err != nil {
 		return nil, err
 	}
-	return SignatureAttribute{n}, nil
+	return SignatureAttribute{nameIndex, n}, nil
 }
 
 func (SignatureAttribute) Name() string {
 	return AttrSignature
 }
 
+func writeSignature(w io.Writer, s SignatureAttribute) error {
+	bw := byteio.BigEndianWriter{Writer: w}
+	_, err := bw.WriteUint16(s.SignatureIndex)
+	return err
+}
+
 type SourceFileAttribute struct {
+	AttributeNameIndex
 	SourceFileIndex uint16
 }
 
-func readSourceFile(r io.Reader) (AttributeInfo, error) {
-	br := byteio.BigEndianReader{Reader: r}
+func readSourceFile(r io.Reader, nameIndex AttributeNameIndex) (AttributeInfo, error) {
+	br := binaryReader{r}
 	n, _, err := br.ReadUint16()
 	if err != nil {
 		return nil, err
 	}
-	return SourceFileAttribute{n}, nil
+	return SourceFileAttribute{nameIndex, n}, nil
 }
 
 func (SourceFileAttribute) Name() string {
 	return AttrSourceFile
 }
 
+func writeSourceFile(w io.Writer, s SourceFileAttribute) error {
+	bw := byteio.BigEndianWriter{Writer: w}
+	_, err := bw.WriteUint16(s.SourceFileIndex)
+	return err
+}
+
 type SourceDebugAttribute struct {
+	AttributeNameIndex
 	DebugExtension string
 }
 
-func readSourceDebugExtension(r io.Reader) (AttributeInfo, error) {
-	data, err := ioutil.ReadAll(r)
+func (d *decoder) readSourceDebugExtension(r io.Reader, nameIndex AttributeNameIndex) (AttributeInfo, error) {
+	data, err := d.readAll(r)
 	if err != nil {
 		return nil, err
 	}
-	return SourceDebugAttribute{string(data)}, nil
+	return SourceDebugAttribute{nameIndex, byteString(data)}, nil
 }
 
 func (SourceDebugAttribute) Name() string {
@@ -401,15 +853,19 @@ type LineNumber struct {
 }
 
 type LineNumberTableAttribute struct {
+	AttributeNameIndex
 	LineNumberTable []LineNumber
 }
 
-func readLineNumberTable(r io.Reader) (AttributeInfo, error) {
-	br := byteio.BigEndianReader{Reader: r}
+func (d *decoder) readLineNumberTable(r io.Reader, nameIndex AttributeNameIndex) (AttributeInfo, error) {
+	br := binaryReader{r}
 	lineNumberTableLength, _, err := br.ReadUint16()
 	if err != nil {
 		return nil, err
 	}
+	if err := reserve[LineNumber](d, r, int(lineNumberTableLength), 4); err != nil {
+		return nil, err
+	}
 	lineNumberTable := make([]LineNumber, lineNumberTableLength)
 	for i := uint16(0); i < lineNumberTableLength; i++ {
 		startPC, _, err := br.ReadUint16()
@@ -425,27 +881,47 @@ func readLineNumberTable(r io.Reader) (AttributeInfo, error) {
 			LineNumber: lineNumber,
 		}
 	}
-	return LineNumberTableAttribute{lineNumberTable}, nil
+	return LineNumberTableAttribute{nameIndex, lineNumberTable}, nil
 }
 
 func (LineNumberTableAttribute) Name() string {
 	return AttrLineNumberTable
 }
 
+func writeLineNumberTable(w io.Writer, l LineNumberTableAttribute) error {
+	if err := writeLength(w, len(l.LineNumberTable)); err != nil {
+		return err
+	}
+	bw := byteio.BigEndianWriter{Writer: w}
+	for _, lineNumber := range l.LineNumberTable {
+		if _, err := bw.WriteUint16(lineNumber.StartPC); err != nil {
+			return err
+		}
+		if _, err := bw.WriteUint16(lineNumber.LineNumber); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 type LocalVariable struct {
 	StartPC, Length, NameIndex, DescriptorIndex, Index uint16
 }
 
 type LocalVariableTableAttribute struct {
+	AttributeNameIndex
 	LocalVariableTable []LocalVariable
 }
 
-func readLocalVariableTable(r io.Reader) (AttributeInfo, error) {
-	br := byteio.BigEndianReader{Reader: r}
+func (d *decoder) readLocalVariableTable(r io.Reader, nameIndex AttributeNameIndex) (AttributeInfo, error) {
+	br := binaryReader{r}
 	localVariableTableLength, _, err := br.ReadUint16()
 	if err != nil {
 		return nil, err
 	}
+	if err := reserve[LocalVariable](d, r, int(localVariableTableLength), 10); err != nil {
+		return nil, err
+	}
 	localVariableTable := make([]LocalVariable, localVariableTableLength)
 	for i := uint16(0); i < localVariableTableLength; i++ {
 		startPC, _, err := br.ReadUint16()
@@ -476,27 +952,56 @@ func readLocalVariableTable(r io.Reader) (AttributeInfo, error) {
 			Index:           index,
 		}
 	}
-	return LocalVariableTableAttribute{localVariableTable}, nil
+	return LocalVariableTableAttribute{nameIndex, localVariableTable}, nil
 }
 
 func (LocalVariableTableAttribute) Name() string {
 	return AttrLocalVariableTable
 }
 
+func writeLocalVariableTable(w io.Writer, l LocalVariableTableAttribute) error {
+	if err := writeLength(w, len(l.LocalVariableTable)); err != nil {
+		return err
+	}
+	bw := byteio.BigEndianWriter{Writer: w}
+	for _, localVariable := range l.LocalVariableTable {
+		if _, err := bw.WriteUint16(localVariable.StartPC); err != nil {
+			return err
+		}
+		if _, err := bw.WriteUint16(localVariable.Length); err != nil {
+			return err
+		}
+		if _, err := bw.WriteUint16(localVariable.NameIndex); err != nil {
+			return err
+		}
+		if _, err := bw.WriteUint16(localVariable.DescriptorIndex); err != nil {
+			return err
+		}
+		if _, err := bw.WriteUint16(localVariable.Index); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 type LocalVariableType struct {
 	StartPC, Length, NameIndex, SignatureIndex, Index uint16
 }
 
 type LocalVariableTypeTableAttribute struct {
+	AttributeNameIndex
 	LocalVariableTypeTable []LocalVariableType
 }
 
-func readLocalVariableTypeTable(r io.Reader) (AttributeInfo, error) {
-	br := byteio.BigEndianReader{Reader: r}
+func (d *decoder) readLocalVariableTypeTable(r io.Reader, nameIndex AttributeNameIndex) (AttributeInfo, error) {
+	br := binaryReader{r}
 	localVariableTypeTableLength, _, err := br.ReadUint16()
 	if err != nil {
 		return nil, err
 	}
+	if err := reserve[LocalVariableType](d, r, int(localVariableTypeTableLength), 10); err != nil {
+		return nil, err
+	}
 	localVariableTypeTable := make([]LocalVariableType, localVariableTypeTableLength)
 	for i := uint16(0); i < localVariableTypeTableLength; i++ {
 		startPC, _, err := br.ReadUint16()
@@ -527,17 +1032,44 @@ func readLocalVariableTypeTable(r io.Reader) (AttributeInfo, error) {
 			Index:          index,
 		}
 	}
-	return LocalVariableTypeTableAttribute{localVariableTypeTable}, nil
+	return LocalVariableTypeTableAttribute{nameIndex, localVariableTypeTable}, nil
 }
 
 func (LocalVariableTypeTableAttribute) Name() string {
 	return AttrLocalVariableTypeTable
 }
 
-type DeprecatedAttribute struct{}
+func writeLocalVariableTypeTable(w io.Writer, l LocalVariableTypeTableAttribute) error {
+	if err := writeLength(w, len(l.LocalVariableTypeTable)); err != nil {
+		return err
+	}
+	bw := byteio.BigEndianWriter{Writer: w}
+	for _, localVariableType := range l.LocalVariableTypeTable {
+		if _, err := bw.WriteUint16(localVariableType.StartPC); err != nil {
+			return err
+		}
+		if _, err := bw.WriteUint16(localVariableType.Length); err != nil {
+			return err
+		}
+		if _, err := bw.WriteUint16(localVariableType.NameIndex); err != nil {
+			return err
+		}
+		if _, err := bw.WriteUint16(localVariableType.SignatureIndex); err != nil {
+			return err
+		}
+		if _, err := bw.WriteUint16(localVariableType.Index); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type DeprecatedAttribute struct {
+	AttributeNameIndex
+}
 
-func readDeprecated(r io.Reader) (AttributeInfo, error) {
-	return nil, nil
+func readDeprecated(r io.Reader, nameIndex AttributeNameIndex) (AttributeInfo, error) {
+	return DeprecatedAttribute{nameIndex}, nil
 }
 
 func (DeprecatedAttribute) Name() string {
@@ -549,21 +1081,24 @@ type ElementValuePair struct {
 	Value            ElementValue
 }
 
-func readElementValuePairs(r io.Reader) ([]ElementValuePair, error) {
-	br := byteio.BigEndianReader{Reader: r}
+func (d *decoder) readElementValuePairs(r io.Reader) ([]ElementValuePair, error) {
+	br := binaryReader{r}
 	numElementValuePairs, _, err := br.ReadUint16()
 	if err != nil {
 		return nil, err
 	}
+	if err := reserve[ElementValuePair](d, r, int(numElementValuePairs), 5); err != nil {
+		return nil, err
+	}
 	elementValuePairs := make([]ElementValuePair, numElementValuePairs)
 	for i := uint16(0); i < numElementValuePairs; i++ {
 		elementNameIndex, _, err := br.ReadUint16()
 		if err != nil {
 			return nil, err
 		}
-		elementValue, err := readElementValue(r)
+		elementValue, err := d.readElementValue(r)
 		if err != nil {
-			return nil, err
+			return nil, indexError(err, "element_value_pairs", int(i))
 		}
 		elementValuePairs[i] = ElementValuePair{
 			ElementNameIndex: elementNameIndex,
@@ -573,18 +1108,34 @@ func readElementValuePairs(r io.Reader) ([]ElementValuePair, error) {
 	return elementValuePairs, nil
 }
 
+func writeElementValuePairs(w io.Writer, elementValuePairs []ElementValuePair) error {
+	if err := writeLength(w, len(elementValuePairs)); err != nil {
+		return err
+	}
+	bw := byteio.BigEndianWriter{Writer: w}
+	for _, elementValuePair := range elementValuePairs {
+		if _, err := bw.WriteUint16(elementValuePair.ElementNameIndex); err != nil {
+			return err
+		}
+		if err := writeElementValue(w, elementValuePair.Value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 type Annotation struct {
 	TypeIndex         uint16
 	ElementValuePairs []ElementValuePair
 }
 
-func readAnnotation(r io.Reader) (Annotation, error) {
-	br := byteio.BigEndianReader{Reader: r}
+func (d *decoder) readAnnotation(r io.Reader) (Annotation, error) {
+	br := binaryReader{r}
 	typeIndex, _, err := br.ReadUint16()
 	if err != nil {
 		return Annotation{}, err
 	}
-	elementValuePairs, err := readElementValuePairs(r)
+	elementValuePairs, err := d.readElementValuePairs(r)
 	if err != nil {
 		return Annotation{}, err
 	}
@@ -594,32 +1145,56 @@ func readAnnotation(r io.Reader) (Annotation, error) {
 	}, nil
 }
 
-func readAnnotations(r io.Reader) ([]Annotation, error) {
-	br := byteio.BigEndianReader{Reader: r}
+func writeAnnotation(w io.Writer, a Annotation) error {
+	bw := byteio.BigEndianWriter{Writer: w}
+	if _, err := bw.WriteUint16(a.TypeIndex); err != nil {
+		return err
+	}
+	return writeElementValuePairs(w, a.ElementValuePairs)
+}
+
+func (d *decoder) readAnnotations(r io.Reader) ([]Annotation, error) {
+	br := binaryReader{r}
 	numAnnotations, _, err := br.ReadUint16()
 	if err != nil {
 		return nil, err
 	}
+	if err := reserve[Annotation](d, r, int(numAnnotations), 4); err != nil {
+		return nil, err
+	}
 	annotations := make([]Annotation, numAnnotations)
 	for i := uint16(0); i < numAnnotations; i++ {
-		annotations[i], err = readAnnotation(r)
+		annotations[i], err = d.readAnnotation(r)
 		if err != nil {
-			return nil, err
+			return nil, indexError(err, "annotations", int(i))
 		}
 	}
 	return annotations, nil
 }
 
+func writeAnnotations(w io.Writer, annotations []Annotation) error {
+	if err := writeLength(w, len(annotations)); err != nil {
+		return err
+	}
+	for _, annotation := range annotations {
+		if err := writeAnnotation(w, annotation); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 type RuntimeVisibleAnnotationsAttribute struct {
+	AttributeNameIndex
 	Annotations []Annotation
 }
 
-func readRuntimeVisibleAnnotations(r io.Reader) (AttributeInfo, error) {
-	annotations, err := readAnnotations(r)
+func (d *decoder) readRuntimeVisibleAnnotations(r io.Reader, nameIndex AttributeNameIndex) (AttributeInfo, error) {
+	annotations, err := d.readAnnotations(r)
 	if err != nil {
 		return nil, err
 	}
-	return RuntimeVisibleAnnotationsAttribute{annotations}, nil
+	return RuntimeVisibleAnnotationsAttribute{nameIndex, annotations}, nil
 }
 
 func (RuntimeVisibleAnnotationsAttribute) Name() string {
@@ -627,15 +1202,16 @@ func (RuntimeVisibleAnnotationsAttribute) Name() string {
 }
 
 type RuntimeInvisibleAnnotationsAttribute struct {
+	AttributeNameIndex
 	Annotations []Annotation
 }
 
-func readRuntimeInvisibleAnnotations(r io.Reader) (AttributeInfo, error) {
-	annotations, err := readAnnotations(r)
+func (d *decoder) readRuntimeInvisibleAnnotations(r io.Reader, nameIndex AttributeNameIndex) (AttributeInfo, error) {
+	annotations, err := d.readAnnotations(r)
 	if err != nil {
 		return nil, err
 	}
-	return RuntimeInvisibleAnnotationsAttribute{annotations}, nil
+	return RuntimeInvisibleAnnotationsAttribute{nameIndex, annotations}, nil
 }
 
 func (RuntimeInvisibleAnnotationsAttribute) Name() string {
@@ -646,33 +1222,53 @@ type ParameterAnnotation struct {
 	Annotations []Annotation
 }
 
-func readParameterAnnotations(r io.Reader) ([]ParameterAnnotation, error) {
-	br := byteio.BigEndianReader{Reader: r}
-	numAnnotations, _, err := br.ReadUint16()
+func (d *decoder) readParameterAnnotations(r io.Reader) ([]ParameterAnnotation, error) {
+	br := binaryReader{r}
+	numAnnotations, _, err := br.ReadUint8()
 	if err != nil {
 		return nil, err
 	}
+	if err := reserve[ParameterAnnotation](d, r, int(numAnnotations), 2); err != nil {
+		return nil, err
+	}
 	parameterAnnotations := make([]ParameterAnnotation, numAnnotations)
-	for i := uint16(0); i < numAnnotations; i++ {
-		annotations, err := readAnnotations(r)
+	for i := uint8(0); i < numAnnotations; i++ {
+		annotations, err := d.readAnnotations(r)
 		if err != nil {
-			return nil, err
+			return nil, indexError(err, "parameter_annotations", int(i))
 		}
 		parameterAnnotations[i] = ParameterAnnotation{annotations}
 	}
 	return parameterAnnotations, nil
 }
 
+func writeParameterAnnotations(w io.Writer, parameterAnnotations []ParameterAnnotation) error {
+	if len(parameterAnnotations) > math.MaxUint8 {
+		return ErrTooLarge
+	}
+	bw := byteio.BigEndianWriter{Writer: w}
+	if _, err := bw.WriteUint8(uint8(len(parameterAnnotations))); err != nil {
+		return err
+	}
+	for _, parameterAnnotation := range parameterAnnotations {
+		if err := writeAnnotations(w, parameterAnnotation.Annotations); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 type RuntimeVisibleParameterAnnotationsAttribute struct {
+	AttributeNameIndex
 	ParameterAnnotations []ParameterAnnotation
 }
 
-func readRuntimeVisibleParameterAnnotations(r io.Reader) (AttributeInfo, error) {
-	parameterAnnotations, err := readParameterAnnotations(r)
+func (d *decoder) readRuntimeVisibleParameterAnnotations(r io.Reader, nameIndex AttributeNameIndex) (AttributeInfo, error) {
+	parameterAnnotations, err := d.readParameterAnnotations(r)
 	if err != nil {
 		return nil, err
 	}
-	return RuntimeVisibleParameterAnnotationsAttribute{parameterAnnotations}, nil
+	return RuntimeVisibleParameterAnnotationsAttribute{nameIndex, parameterAnnotations}, nil
 }
 
 func (RuntimeVisibleParameterAnnotationsAttribute) Name() string {
@@ -680,27 +1276,67 @@ func (RuntimeVisibleParameterAnnotationsAttribute) Name() string {
 }
 
 type RuntimeInvisibleParameterAnnotationsAttribute struct {
+	AttributeNameIndex
 	ParameterAnnotations []ParameterAnnotation
 }
 
-func readRuntimeInvisibleParameterAnnotations(r io.Reader) (AttributeInfo, error) {
-	parameterAnnotations, err := readParameterAnnotations(r)
+func (d *decoder) readRuntimeInvisibleParameterAnnotations(r io.Reader, nameIndex AttributeNameIndex) (AttributeInfo, error) {
+	parameterAnnotations, err := d.readParameterAnnotations(r)
 	if err != nil {
 		return nil, err
 	}
-	return RuntimeInvisibleParameterAnnotationsAttribute{parameterAnnotations}, nil
+	return RuntimeInvisibleParameterAnnotationsAttribute{nameIndex, parameterAnnotations}, nil
 }
 
 func (RuntimeInvisibleParameterAnnotationsAttribute) Name() string {
 	return AttrRuntimeInvisibleParameterAnnotations
 }
 
+type RuntimeVisibleTypeAnnotationsAttribute struct {
+	AttributeNameIndex
+	Annotations []TypeAnnotation
+}
+
+func (d *decoder) readRuntimeVisibleTypeAnnotations(r io.Reader, nameIndex AttributeNameIndex) (AttributeInfo, error) {
+	typeAnnotations, err := d.readTypeAnnotations(r)
+	if err != nil {
+		return nil, err
+	}
+	return RuntimeVisibleTypeAnnotationsAttribute{nameIndex, typeAnnotations}, nil
+}
+
+func (RuntimeVisibleTypeAnnotationsAttribute) Name() string {
+	return AttrRuntimeVisibleTypeAnnotations
+}
+
+type RuntimeInvisibleTypeAnnotationsAttribute struct {
+	AttributeNameIndex
+	Annotations []TypeAnnotation
+}
+
+func (d *decoder) readRuntimeInvisibleTypeAnnotations(r io.Reader, nameIndex AttributeNameIndex) (AttributeInfo, error) {
+	typeAnnotations, err := d.readTypeAnnotations(r)
+	if err != nil {
+		return nil, err
+	}
+	return RuntimeInvisibleTypeAnnotationsAttribute{nameIndex, typeAnnotations}, nil
+}
+
+func (RuntimeInvisibleTypeAnnotationsAttribute) Name() string {
+	return AttrRuntimeInvisibleTypeAnnotations
+}
+
 type AnnotationDefaultAttribute struct {
+	AttributeNameIndex
 	DefaultValue ElementValue
 }
 
-func readAnnotationDefault(r io.Reader) (AttributeInfo, error) {
-	return nil, nil
+func (d *decoder) readAnnotationDefault(r io.Reader, nameIndex AttributeNameIndex) (AttributeInfo, error) {
+	elementValue, err := d.readElementValue(r)
+	if err != nil {
+		return nil, pathError(err, "default_value")
+	}
+	return AnnotationDefaultAttribute{nameIndex, elementValue}, nil
 }
 
 func (AnnotationDefaultAttribute) Name() string {
@@ -713,21 +1349,263 @@ type BootstrapMethod struct {
 }
 
 type BootstrapMethodsAttribute struct {
+	AttributeNameIndex
 	BootstrapMethods []BootstrapMethod
 }
 
-func readBootstrapMethods(r io.Reader) (AttributeInfo, error) {
-	return nil, nil
+func (d *decoder) readBootstrapMethods(r io.Reader, nameIndex AttributeNameIndex) (AttributeInfo, error) {
+	br := binaryReader{r}
+	numBootstrapMethods, _, err := br.ReadUint16()
+	if err != nil {
+		return nil, err
+	}
+	if err := reserve[BootstrapMethod](d, r, int(numBootstrapMethods), 4); err != nil {
+		return nil, err
+	}
+	bootstrapMethods := make([]BootstrapMethod, numBootstrapMethods)
+	for i := uint16(0); i < numBootstrapMethods; i++ {
+		bootstrapMethodRef, _, err := br.ReadUint16()
+		if err != nil {
+			return nil, err
+		}
+		numBootstrapArguments, _, err := br.ReadUint16()
+		if err != nil {
+			return nil, err
+		}
+		if err := reserve[uint16](d, r, int(numBootstrapArguments), 2); err != nil {
+			return nil, err
+		}
+		bootstrapArguments := make([]uint16, numBootstrapArguments)
+		for j := uint16(0); j < numBootstrapArguments; j++ {
+			bootstrapArguments[j], _, err = br.ReadUint16()
+			if err != nil {
+				return nil, err
+			}
+		}
+		bootstrapMethods[i] = BootstrapMethod{
+			BootstrapMethodRef: bootstrapMethodRef,
+			BootstrapArguments: bootstrapArguments,
+		}
+	}
+	return BootstrapMethodsAttribute{nameIndex, bootstrapMethods}, nil
 }
 
 func (BootstrapMethodsAttribute) Name() string {
 	return AttrBootstrapMethods
 }
 
+func writeBootstrapMethods(w io.Writer, b BootstrapMethodsAttribute) error {
+	if err := writeLength(w, len(b.BootstrapMethods)); err != nil {
+		return err
+	}
+	bw := byteio.BigEndianWriter{Writer: w}
+	for _, bootstrapMethod := range b.BootstrapMethods {
+		if _, err := bw.WriteUint16(bootstrapMethod.BootstrapMethodRef); err != nil {
+			return err
+		}
+		if err := writeLength(w, len(bootstrapMethod.BootstrapArguments)); err != nil {
+			return err
+		}
+		for _, argument := range bootstrapMethod.BootstrapArguments {
+			if _, err := bw.WriteUint16(argument); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+type NestHostAttribute struct {
+	AttributeNameIndex
+	HostClassIndex uint16
+}
+
+func readNestHost(r io.Reader, nameIndex AttributeNameIndex) (AttributeInfo, error) {
+	br := binaryReader{r}
+	n, _, err := br.ReadUint16()
+	if err != nil {
+		return nil, err
+	}
+	return NestHostAttribute{nameIndex, n}, nil
+}
+
+func (NestHostAttribute) Name() string {
+	return AttrNestHost
+}
+
+func writeNestHost(w io.Writer, n NestHostAttribute) error {
+	bw := byteio.BigEndianWriter{Writer: w}
+	_, err := bw.WriteUint16(n.HostClassIndex)
+	return err
+}
+
+type NestMembersAttribute struct {
+	AttributeNameIndex
+	Classes []uint16
+}
+
+func (d *decoder) readNestMembers(r io.Reader, nameIndex AttributeNameIndex) (AttributeInfo, error) {
+	classes, err := d.readIndexTable(r)
+	if err != nil {
+		return nil, err
+	}
+	return NestMembersAttribute{nameIndex, classes}, nil
+}
+
+func (NestMembersAttribute) Name() string {
+	return AttrNestMembers
+}
+
+type RecordComponentInfo struct {
+	NameIndex, DescriptorIndex uint16
+	Attributes                 []AttributeInfo
+}
+
+type RecordAttribute struct {
+	AttributeNameIndex
+	Components []RecordComponentInfo
+}
+
+func (d *decoder) readRecord(r io.Reader, nameIndex AttributeNameIndex) (AttributeInfo, error) {
+	br := binaryReader{r}
+	componentsCount, _, err := br.ReadUint16()
+	if err != nil {
+		return nil, err
+	}
+	if err := reserve[RecordComponentInfo](d, r, int(componentsCount), 6); err != nil {
+		return nil, err
+	}
+	components := make([]RecordComponentInfo, componentsCount)
+	for i := uint16(0); i < componentsCount; i++ {
+		nameIndex, _, err := br.ReadUint16()
+		if err != nil {
+			return nil, err
+		}
+		descriptorIndex, _, err := br.ReadUint16()
+		if err != nil {
+			return nil, err
+		}
+		attributes, err := d.readAttributes(r, locationRecordComponent)
+		if err != nil {
+			return nil, indexError(err, "components", int(i))
+		}
+		components[i] = RecordComponentInfo{
+			NameIndex:       nameIndex,
+			DescriptorIndex: descriptorIndex,
+			Attributes:      attributes,
+		}
+	}
+	return RecordAttribute{nameIndex, components}, nil
+}
+
+func (RecordAttribute) Name() string {
+	return AttrRecord
+}
+
+func (e *encoder) writeRecord(w io.Writer, r RecordAttribute) error {
+	if err := writeLength(w, len(r.Components)); err != nil {
+		return err
+	}
+	bw := byteio.BigEndianWriter{Writer: w}
+	for _, component := range r.Components {
+		if _, err := bw.WriteUint16(component.NameIndex); err != nil {
+			return err
+		}
+		if _, err := bw.WriteUint16(component.DescriptorIndex); err != nil {
+			return err
+		}
+		if err := e.writeAttributes(w, component.Attributes); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type PermittedSubclassesAttribute struct {
+	AttributeNameIndex
+	Classes []uint16
+}
+
+func (d *decoder) readPermittedSubclasses(r io.Reader, nameIndex AttributeNameIndex) (AttributeInfo, error) {
+	classes, err := d.readIndexTable(r)
+	if err != nil {
+		return nil, err
+	}
+	return PermittedSubclassesAttribute{nameIndex, classes}, nil
+}
+
+func (PermittedSubclassesAttribute) Name() string {
+	return AttrPermittedSubclasses
+}
+
+type MethodParameter struct {
+	NameIndex   uint16
+	AccessFlags ParameterAccess
+}
+
+type MethodParametersAttribute struct {
+	AttributeNameIndex
+	Parameters []MethodParameter
+}
+
+func (d *decoder) readMethodParameters(r io.Reader, nameIndex AttributeNameIndex) (AttributeInfo, error) {
+	br := binaryReader{r}
+	parametersCount, _, err := br.ReadUint8()
+	if err != nil {
+		return nil, err
+	}
+	if err := reserve[MethodParameter](d, r, int(parametersCount), 4); err != nil {
+		return nil, err
+	}
+	parameters := make([]MethodParameter, parametersCount)
+	for i := uint8(0); i < parametersCount; i++ {
+		nameIndex, _, err := br.ReadUint16()
+		if err != nil {
+			return nil, err
+		}
+		accessFlags, _, err := br.ReadUint16()
+		if err != nil {
+			return nil, err
+		}
+		parameters[i] = MethodParameter{
+			NameIndex:   nameIndex,
+			AccessFlags: ParameterAccess(accessFlags),
+		}
+	}
+	return MethodParametersAttribute{nameIndex, parameters}, nil
+}
+
+func (MethodParametersAttribute) Name() string {
+	return AttrMethodParameters
+}
+
+func writeMethodParameters(w io.Writer, m MethodParametersAttribute) error {
+	if len(m.Parameters) > math.MaxUint8 {
+		return ErrTooLarge
+	}
+	bw := byteio.BigEndianWriter{Writer: w}
+	if _, err := bw.WriteUint8(uint8(len(m.Parameters))); err != nil {
+		return err
+	}
+	for _, parameter := range m.Parameters {
+		if _, err := bw.WriteUint16(parameter.NameIndex); err != nil {
+			return err
+		}
+		if _, err := bw.WriteUint16(uint16(parameter.AccessFlags)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 //Errors
 
 var (
 	ErrInvalidConstantPoolIndex = errors.New("invalid constant pool index")
 	ErrInvalidConstantPoolType  = errors.New("invalid constant pool type")
 	ErrInvalidAttributeName     = errors.New("invalid attribute name")
+	ErrUnknownAttributeType     = errors.New("unknown attribute type")
+	ErrMissingAttributeName     = errors.New("attribute name not in constant pool")
+	ErrAttributeLength          = errors.New("attribute length does not match contents")
+	ErrDuplicateAttribute       = errors.New("duplicate attribute")
 )
@@ -0,0 +1,91 @@
+package javaclass
+
+import "errors"
+
+const (
+	lambdaMetafactory = "java/lang/invoke/LambdaMetafactory"
+	metafactory       = "metafactory"
+	altMetafactory    = "altMetafactory"
+)
+
+// CallSite is a resolved invokedynamic call site or dynamically-computed
+// constant: the bootstrap method, its static arguments, and the name and
+// descriptor that are passed to it.
+type CallSite struct {
+	BootstrapMethod  MethodHandle
+	Arguments        []CPInfo
+	Name, Descriptor string
+}
+
+// BootstrapMethods returns the entries of the BootstrapMethods attribute of the
+// class, or nil when it has none.
+func (c *Class) BootstrapMethods() []BootstrapMethod {
+	for _, attribute := range c.Attributes {
+		if b, ok := attribute.(BootstrapMethodsAttribute); ok {
+			return b.BootstrapMethods
+		}
+	}
+	return nil
+}
+
+// ResolveInvokeDynamic resolves the bootstrap method, static arguments, name
+// and descriptor of an invokedynamic call site.
+func (c *Class) ResolveInvokeDynamic(indy ConstantInvokeDynamicInfo) (CallSite, error) {
+	return c.resolveCallSite(indy.BootstrapMethodAttrIndex, indy.NameAndTypeIndex)
+}
+
+// ResolveDynamic resolves the bootstrap method, static arguments, name and
+// descriptor of a dynamically-computed constant.
+func (c *Class) ResolveDynamic(condy ConstantDynamicInfo) (CallSite, error) {
+	return c.resolveCallSite(condy.BootstrapMethodAttrIndex, condy.NameAndTypeIndex)
+}
+
+func (c *Class) resolveCallSite(bootstrapMethodAttrIndex, nameAndTypeIndex uint16) (CallSite, error) {
+	bootstrapMethods := c.BootstrapMethods()
+	if int(bootstrapMethodAttrIndex) >= len(bootstrapMethods) {
+		return CallSite{}, ErrInvalidBootstrapMethod
+	}
+	bootstrapMethod := bootstrapMethods[bootstrapMethodAttrIndex]
+	handle, err := c.MethodHandle(bootstrapMethod.BootstrapMethodRef)
+	if err != nil {
+		return CallSite{}, err
+	}
+	arguments := make([]CPInfo, len(bootstrapMethod.BootstrapArguments))
+	for n, argument := range bootstrapMethod.BootstrapArguments {
+		arguments[n], err = c.constant(argument)
+		if err != nil {
+			return CallSite{}, err
+		}
+	}
+	name, descriptor, err := c.NameAndType(nameAndTypeIndex)
+	if err != nil {
+		return CallSite{}, err
+	}
+	return CallSite{
+		BootstrapMethod: handle,
+		Arguments:       arguments,
+		Name:            name,
+		Descriptor:      descriptor,
+	}, nil
+}
+
+// LambdaImplementation returns the method that implements the lambda or method
+// reference created at an invokedynamic call site bootstrapped by
+// LambdaMetafactory.
+func (c *Class) LambdaImplementation(indy ConstantInvokeDynamicInfo) (MethodHandle, error) {
+	callSite, err := c.ResolveInvokeDynamic(indy)
+	if err != nil {
+		return MethodHandle{}, err
+	}
+	if callSite.BootstrapMethod.Owner != lambdaMetafactory || (callSite.BootstrapMethod.Name != metafactory && callSite.BootstrapMethod.Name != altMetafactory) || len(callSite.Arguments) < 2 {
+		return MethodHandle{}, ErrNotLambda
+	}
+	return c.MethodHandle(c.BootstrapMethods()[indy.BootstrapMethodAttrIndex].BootstrapArguments[1])
+}
+
+//Errors
+
+var (
+	ErrInvalidBootstrapMethod = errors.New("invalid bootstrap method index")
+	ErrNotLambda              = errors.New("call site is not a lambda")
+)
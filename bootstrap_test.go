@@ -0,0 +1,110 @@
+package javaclass
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+const lambdaMetafactoryDescriptor = "(Ljava/lang/invoke/MethodHandles$Lookup;Ljava/lang/String;Ljava/lang/invoke/MethodType;Ljava/lang/invoke/MethodType;Ljava/lang/invoke/MethodHandle;Ljava/lang/invoke/MethodType;)Ljava/lang/invoke/CallSite;"
+
+// lambdaClass returns a class with an invokedynamic call site creating a
+// Runnable from the method lambda$main$0, and a dynamically-computed constant
+// bootstrapped by that method.
+func lambdaClass() *Class {
+	return &Class{
+		ConstantPool: []CPInfo{
+			nil,
+			ConstantUTF8Info{String: "A"},
+			ConstantClassInfo{NameIndex: 1},
+			ConstantUTF8Info{String: lambdaMetafactory},
+			ConstantClassInfo{NameIndex: 3},
+			ConstantUTF8Info{String: metafactory},
+			ConstantUTF8Info{String: lambdaMetafactoryDescriptor},
+			ConstantNameAndTypeInfo{NameIndex: 5, DescriptorIndex: 6},
+			ConstantMethodRefInfo{ClassIndex: 4, NameAndTypeIndex: 7},
+			ConstantMethodHandleInfo{ReferenceKind: RefInvokeStatic, ReferenceIndex: 8},
+			ConstantUTF8Info{String: "()V"},
+			ConstantMethodTypeInfo{DescriptorIndex: 10},
+			ConstantUTF8Info{String: "lambda$main$0"},
+			ConstantNameAndTypeInfo{NameIndex: 12, DescriptorIndex: 10},
+			ConstantMethodRefInfo{ClassIndex: 2, NameAndTypeIndex: 13},
+			ConstantMethodHandleInfo{ReferenceKind: RefInvokeStatic, ReferenceIndex: 14},
+			ConstantUTF8Info{String: "run"},
+			ConstantUTF8Info{String: "()Ljava/lang/Runnable;"},
+			ConstantNameAndTypeInfo{NameIndex: 16, DescriptorIndex: 17},
+			ConstantInvokeDynamicInfo{BootstrapMethodAttrIndex: 0, NameAndTypeIndex: 18},
+			ConstantUTF8Info{String: "x"},
+			ConstantUTF8Info{String: "I"},
+			ConstantNameAndTypeInfo{NameIndex: 20, DescriptorIndex: 21},
+			ConstantDynamicInfo{BootstrapMethodAttrIndex: 1, NameAndTypeIndex: 22},
+		},
+		ThisClass: 2,
+		Attributes: []AttributeInfo{
+			BootstrapMethodsAttribute{BootstrapMethods: []BootstrapMethod{
+				{BootstrapMethodRef: 9, BootstrapArguments: []uint16{11, 15, 11}},
+				{BootstrapMethodRef: 15, BootstrapArguments: []uint16{}},
+			}},
+		},
+	}
+}
+
+func TestResolveCallSite(t *testing.T) {
+	c := lambdaClass()
+	metafactoryHandle := MethodHandle{ReferenceKind: RefInvokeStatic, Owner: lambdaMetafactory, Name: metafactory, Descriptor: lambdaMetafactoryDescriptor}
+	lambdaHandle := MethodHandle{ReferenceKind: RefInvokeStatic, Owner: "A", Name: "lambda$main$0", Descriptor: "()V"}
+	if b := c.BootstrapMethods(); len(b) != 2 || b[0].BootstrapMethodRef != 9 {
+		t.Errorf("expecting bootstrap methods from attribute, got %v", b)
+	}
+	if b := new(Class).BootstrapMethods(); b != nil {
+		t.Errorf("expecting no bootstrap methods, got %v", b)
+	}
+	callSite, err := c.ResolveInvokeDynamic(c.ConstantPool[19].(ConstantInvokeDynamicInfo))
+	if expected := (CallSite{
+		BootstrapMethod: metafactoryHandle,
+		Arguments:       []CPInfo{c.ConstantPool[11], c.ConstantPool[15], c.ConstantPool[11]},
+		Name:            "run",
+		Descriptor:      "()Ljava/lang/Runnable;",
+	}); err != nil || !reflect.DeepEqual(callSite, expected) {
+		t.Errorf("expecting call site %v, got %v (%v)", expected, callSite, err)
+	}
+	callSite, err = c.ResolveDynamic(c.ConstantPool[23].(ConstantDynamicInfo))
+	if expected := (CallSite{
+		BootstrapMethod: lambdaHandle,
+		Arguments:       []CPInfo{},
+		Name:            "x",
+		Descriptor:      "I",
+	}); err != nil || !reflect.DeepEqual(callSite, expected) {
+		t.Errorf("expecting dynamic constant %v, got %v (%v)", expected, callSite, err)
+	}
+	if _, err := c.ResolveInvokeDynamic(ConstantInvokeDynamicInfo{BootstrapMethodAttrIndex: 2, NameAndTypeIndex: 18}); err != ErrInvalidBootstrapMethod {
+		t.Errorf("expecting error %v, got %v", ErrInvalidBootstrapMethod, err)
+	}
+	if _, err := c.ResolveInvokeDynamic(ConstantInvokeDynamicInfo{BootstrapMethodAttrIndex: 0, NameAndTypeIndex: 17}); !errors.Is(err, ErrInvalidConstantPoolType) {
+		t.Errorf("expecting error %v, got %v", ErrInvalidConstantPoolType, err)
+	}
+	if _, err := new(Class).ResolveDynamic(ConstantDynamicInfo{}); err != ErrInvalidBootstrapMethod {
+		t.Errorf("expecting error %v, got %v", ErrInvalidBootstrapMethod, err)
+	}
+}
+
+func TestLambdaImplementation(t *testing.T) {
+	c := lambdaClass()
+	lambdaHandle := MethodHandle{ReferenceKind: RefInvokeStatic, Owner: "A", Name: "lambda$main$0", Descriptor: "()V"}
+	for n, test := range [...]struct {
+		CallSite ConstantInvokeDynamicInfo
+		Handle   MethodHandle
+		Err      error
+	}{
+		{c.ConstantPool[19].(ConstantInvokeDynamicInfo), lambdaHandle, nil},
+		{ConstantInvokeDynamicInfo{BootstrapMethodAttrIndex: 1, NameAndTypeIndex: 18}, MethodHandle{}, ErrNotLambda},
+		{ConstantInvokeDynamicInfo{BootstrapMethodAttrIndex: 2, NameAndTypeIndex: 18}, MethodHandle{}, ErrInvalidBootstrapMethod},
+	} {
+		handle, err := c.LambdaImplementation(test.CallSite)
+		if err != test.Err {
+			t.Errorf("test %d: expecting error %v, got %v", n+1, test.Err, err)
+		} else if handle != test.Handle {
+			t.Errorf("test %d: expecting handle %v, got %v", n+1, test.Handle, handle)
+		}
+	}
+}
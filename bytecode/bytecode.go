@@ -0,0 +1,298 @@
+// Package bytecode decodes the instructions in the code array of a Code
+// attribute.
+package bytecode // import "vimagination.zapto.org/javaclass/bytecode"
+
+import (
+	"encoding/binary"
+	"errors"
+	"strconv"
+)
+
+type format uint8
+
+const (
+	noOperands format = iota
+	implicit
+	byteValue
+	shortValue
+	constant1
+	constant2
+	local
+	iinc
+	branch2
+	branch4
+	tableSwitch
+	lookupSwitch
+	invokeInterface
+	invokeDynamic
+	newArray
+	multiANewArray
+	wide
+)
+
+// String returns the mnemonic of the opcode, such as invokevirtual.
+func (o Opcode) String() string {
+	if name := opcodes[o].name; name != "" {
+		return name
+	}
+	return "opcode(" + strconv.Itoa(int(o)) + ")"
+}
+
+// Instruction is a decoded instruction.
+//
+// The operands used by each opcode are:
+//
+//   - Index: the constant pool index of ldc, field and method instructions,
+//     new, anewarray, checkcast, instanceof and multianewarray; and the local
+//     variable index of loads, stores, ret and iinc, including the implicit
+//     index of forms such as iload_0.
+//   - Value: the value pushed by bipush and sipush, the increment of iinc,
+//     the atype of newarray, the dimensions of multianewarray and the count
+//     of invokeinterface.
+//   - Target: the absolute pc of the target of branches, and the default
+//     target of tableswitch and lookupswitch.
+//   - Keys and Targets: the match values of tableswitch and lookupswitch,
+//     and the absolute pc to jump to for each.
+type Instruction struct {
+	PC     int
+	Opcode Opcode
+
+	// Wide is set when the instruction is modified by a wide prefix,
+	// which is included in its Size.
+	Wide bool
+	Size int
+
+	Index   uint16
+	Value   int32
+	Target  int
+	Keys    []int32
+	Targets []int
+}
+
+func (i Instruction) String() string {
+	s := strconv.Itoa(i.PC) + ": "
+	if i.Wide {
+		s += "wide "
+	}
+	s += i.Opcode.String()
+	switch opcodes[i.Opcode].format {
+	case byteValue, shortValue, newArray:
+		s += " " + strconv.Itoa(int(i.Value))
+	case constant1, constant2, local:
+		s += " #" + strconv.Itoa(int(i.Index))
+	case iinc:
+		s += " " + strconv.Itoa(int(i.Index)) + ", " + strconv.Itoa(int(i.Value))
+	case branch2, branch4:
+		s += " " + strconv.Itoa(i.Target)
+	case tableSwitch, lookupSwitch:
+		s += " {"
+		for n, k := range i.Keys {
+			s += " " + strconv.Itoa(int(k)) + ": " + strconv.Itoa(i.Targets[n]) + ";"
+		}
+		s += " default: " + strconv.Itoa(i.Target) + " }"
+	case invokeInterface, multiANewArray:
+		s += " #" + strconv.Itoa(int(i.Index)) + ", " + strconv.Itoa(int(i.Value))
+	case invokeDynamic:
+		s += " #" + strconv.Itoa(int(i.Index)) + ", 0"
+	}
+	return s
+}
+
+// Decode decodes every instruction in a code array.
+func Decode(code []byte) ([]Instruction, error) {
+	var instructions []Instruction
+	for pc := 0; pc < len(code); {
+		i, err := DecodeInstruction(code, pc)
+		if err != nil {
+			return nil, err
+		}
+		instructions = append(instructions, i)
+		pc += i.Size
+	}
+	return instructions, nil
+}
+
+// DecodeInstruction decodes the instruction starting at the given pc of a code
+// array.
+//
+// Errors are returned as a *DecodeError.
+func DecodeInstruction(code []byte, pc int) (Instruction, error) {
+	if pc < 0 || pc >= len(code) {
+		return Instruction{}, &DecodeError{PC: pc, Err: ErrTruncated}
+	}
+	d := decoder{code: code, pos: pc + 1}
+	i := Instruction{
+		PC:     pc,
+		Opcode: Opcode(code[pc]),
+	}
+	if err := d.decode(&i); err != nil {
+		return Instruction{}, &DecodeError{PC: pc, Opcode: i.Opcode, Err: err}
+	}
+	i.Size = d.pos - pc
+	return i, nil
+}
+
+type decoder struct {
+	code []byte
+	pos  int
+	err  error
+}
+
+func (d *decoder) bytes(n int) []byte {
+	if d.err != nil {
+		return make([]byte, n)
+	} else if n > len(d.code)-d.pos {
+		d.err = ErrTruncated
+		return make([]byte, n)
+	}
+	b := d.code[d.pos : d.pos+n]
+	d.pos += n
+	return b
+}
+
+func (d *decoder) u1() uint8 {
+	return d.bytes(1)[0]
+}
+
+func (d *decoder) u2() uint16 {
+	return binary.BigEndian.Uint16(d.bytes(2))
+}
+
+func (d *decoder) s4() int32 {
+	return int32(binary.BigEndian.Uint32(d.bytes(4)))
+}
+
+func (d *decoder) decode(i *Instruction) error {
+	switch opcodes[i.Opcode].format {
+	case noOperands:
+		if opcodes[i.Opcode].name == "" {
+			return ErrUnknownOpcode
+		}
+	case implicit:
+		i.Index = implicitLocal(i.Opcode)
+	case byteValue:
+		i.Value = int32(int8(d.u1()))
+	case shortValue:
+		i.Value = int32(int16(d.u2()))
+	case constant1, local:
+		i.Index = uint16(d.u1())
+	case constant2:
+		i.Index = d.u2()
+	case iinc:
+		i.Index = uint16(d.u1())
+		i.Value = int32(int8(d.u1()))
+	case branch2:
+		i.Target = i.PC + int(int16(d.u2()))
+	case branch4:
+		i.Target = i.PC + int(d.s4())
+	case tableSwitch:
+		d.align()
+		i.Target = i.PC + int(d.s4())
+		low, high := d.s4(), d.s4()
+		if d.err != nil {
+			return d.err
+		} else if low > high {
+			return ErrInvalidSwitch
+		}
+		n := int64(high) - int64(low) + 1
+		if n*4 > int64(len(d.code)-d.pos) {
+			return ErrTruncated
+		}
+		i.Keys = make([]int32, n)
+		i.Targets = make([]int, n)
+		for k := range i.Keys {
+			i.Keys[k] = low + int32(k)
+			i.Targets[k] = i.PC + int(d.s4())
+		}
+	case lookupSwitch:
+		d.align()
+		i.Target = i.PC + int(d.s4())
+		n := d.s4()
+		if d.err != nil {
+			return d.err
+		} else if n < 0 {
+			return ErrInvalidSwitch
+		} else if int64(n)*8 > int64(len(d.code)-d.pos) {
+			return ErrTruncated
+		}
+		i.Keys = make([]int32, n)
+		i.Targets = make([]int, n)
+		for k := range i.Keys {
+			i.Keys[k] = d.s4()
+			i.Targets[k] = i.PC + int(d.s4())
+		}
+	case invokeInterface:
+		i.Index = d.u2()
+		i.Value = int32(d.u1())
+		if d.u1() != 0 && d.err == nil {
+			return ErrInvalidOperand
+		}
+	case invokeDynamic:
+		i.Index = d.u2()
+		if d.u2() != 0 && d.err == nil {
+			return ErrInvalidOperand
+		}
+	case newArray:
+		i.Value = int32(d.u1())
+	case multiANewArray:
+		i.Index = d.u2()
+		i.Value = int32(d.u1())
+	case wide:
+		i.Wide = true
+		i.Opcode = Opcode(d.u1())
+		if d.err != nil {
+			return d.err
+		}
+		switch f := opcodes[i.Opcode].format; {
+		case f == local:
+			i.Index = d.u2()
+		case f == iinc:
+			i.Index = d.u2()
+			i.Value = int32(int16(d.u2()))
+		default:
+			return ErrInvalidWide
+		}
+	}
+	return d.err
+}
+
+// align skips the padding that follows tableswitch and lookupswitch, so that
+// their operands start at a multiple of four bytes from the start of the code.
+func (d *decoder) align() {
+	d.bytes((4 - d.pos%4) % 4)
+}
+
+func implicitLocal(o Opcode) uint16 {
+	switch {
+	case o >= ILoad0 && o <= ALoad3:
+		return uint16(o-ILoad0) % 4
+	case o >= IStore0 && o <= AStore3:
+		return uint16(o-IStore0) % 4
+	}
+	return 0
+}
+
+// DecodeError is returned when an instruction cannot be decoded.
+type DecodeError struct {
+	PC     int
+	Opcode Opcode
+	Err    error
+}
+
+func (d *DecodeError) Error() string {
+	return d.Err.Error() + " (" + d.Opcode.String() + ") at pc " + strconv.Itoa(d.PC)
+}
+
+func (d *DecodeError) Unwrap() error {
+	return d.Err
+}
+
+//Errors
+
+var (
+	ErrTruncated      = errors.New("truncated instruction")
+	ErrUnknownOpcode  = errors.New("unknown opcode")
+	ErrInvalidWide    = errors.New("invalid opcode for wide")
+	ErrInvalidSwitch  = errors.New("invalid switch bounds")
+	ErrInvalidOperand = errors.New("invalid operand")
+)
@@ -0,0 +1,223 @@
+package bytecode
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestDecodeInstruction(t *testing.T) {
+	for n, test := range [...]struct {
+		Code        []byte
+		PC          int
+		Instruction Instruction
+		String      string
+	}{
+		{
+			Code:        []byte{0x00},
+			Instruction: Instruction{Opcode: Nop, Size: 1},
+			String:      "0: nop",
+		},
+		{
+			Code:        []byte{0x2a},
+			Instruction: Instruction{Opcode: ALoad0, Size: 1},
+			String:      "0: aload_0",
+		},
+		{
+			Code:        []byte{0x00, 0x3e},
+			PC:          1,
+			Instruction: Instruction{PC: 1, Opcode: IStore3, Index: 3, Size: 1},
+			String:      "1: istore_3",
+		},
+		{
+			Code:        []byte{0x10, 0xfe},
+			Instruction: Instruction{Opcode: BIPush, Value: -2, Size: 2},
+			String:      "0: bipush -2",
+		},
+		{
+			Code:        []byte{0x11, 0x80, 0x00},
+			Instruction: Instruction{Opcode: SIPush, Value: -32768, Size: 3},
+			String:      "0: sipush -32768",
+		},
+		{
+			Code:        []byte{0x12, 0xff},
+			Instruction: Instruction{Opcode: Ldc, Index: 255, Size: 2},
+			String:      "0: ldc #255",
+		},
+		{
+			Code:        []byte{0x14, 0x01, 0x02},
+			Instruction: Instruction{Opcode: Ldc2W, Index: 0x102, Size: 3},
+			String:      "0: ldc2_w #258",
+		},
+		{
+			Code:        []byte{0x19, 0x05},
+			Instruction: Instruction{Opcode: ALoad, Index: 5, Size: 2},
+			String:      "0: aload #5",
+		},
+		{
+			Code:        []byte{0x84, 0x01, 0xff},
+			Instruction: Instruction{Opcode: IInc, Index: 1, Value: -1, Size: 3},
+			String:      "0: iinc 1, -1",
+		},
+		{
+			Code:        []byte{0x00, 0x00, 0x99, 0xff, 0xfe},
+			PC:          2,
+			Instruction: Instruction{PC: 2, Opcode: IfEq, Target: 0, Size: 3},
+			String:      "2: ifeq 0",
+		},
+		{
+			Code:        []byte{0xc8, 0x00, 0x01, 0x00, 0x00},
+			Instruction: Instruction{Opcode: GotoW, Target: 65536, Size: 5},
+			String:      "0: goto_w 65536",
+		},
+		{
+			Code:        []byte{0xb6, 0x00, 0x07},
+			Instruction: Instruction{Opcode: InvokeVirtual, Index: 7, Size: 3},
+			String:      "0: invokevirtual #7",
+		},
+		{
+			Code:        []byte{0xb9, 0x00, 0x08, 0x02, 0x00},
+			Instruction: Instruction{Opcode: InvokeInterface, Index: 8, Value: 2, Size: 5},
+			String:      "0: invokeinterface #8, 2",
+		},
+		{
+			Code:        []byte{0xba, 0x00, 0x09, 0x00, 0x00},
+			Instruction: Instruction{Opcode: InvokeDynamic, Index: 9, Size: 5},
+			String:      "0: invokedynamic #9, 0",
+		},
+		{
+			Code:        []byte{0xbc, 0x0a},
+			Instruction: Instruction{Opcode: NewArray, Value: 10, Size: 2},
+			String:      "0: newarray 10",
+		},
+		{
+			Code:        []byte{0xc5, 0x00, 0x03, 0x02},
+			Instruction: Instruction{Opcode: MultiANewArray, Index: 3, Value: 2, Size: 4},
+			String:      "0: multianewarray #3, 2",
+		},
+		{
+			Code:        []byte{0xc4, 0x15, 0x01, 0x00},
+			Instruction: Instruction{Opcode: ILoad, Wide: true, Index: 256, Size: 4},
+			String:      "0: wide iload #256",
+		},
+		{
+			Code:        []byte{0xc4, 0x84, 0x01, 0x00, 0xff, 0x00},
+			Instruction: Instruction{Opcode: IInc, Wide: true, Index: 256, Value: -256, Size: 6},
+			String:      "0: wide iinc 256, -256",
+		},
+		{
+			Code: []byte{
+				0x00, 0xaa, 0x00, 0x00,
+				0x00, 0x00, 0x00, 0x20,
+				0x00, 0x00, 0x00, 0x01,
+				0x00, 0x00, 0x00, 0x02,
+				0x00, 0x00, 0x00, 0x10,
+				0xff, 0xff, 0xff, 0xff,
+			},
+			PC: 1,
+			Instruction: Instruction{
+				PC:      1,
+				Opcode:  TableSwitch,
+				Target:  33,
+				Keys:    []int32{1, 2},
+				Targets: []int{17, 0},
+				Size:    23,
+			},
+			String: "1: tableswitch { 1: 17; 2: 0; default: 33 }",
+		},
+		{
+			Code: []byte{
+				0xab, 0x00, 0x00, 0x00,
+				0x00, 0x00, 0x00, 0x14,
+				0x00, 0x00, 0x00, 0x01,
+				0xff, 0xff, 0xff, 0xf6,
+				0x00, 0x00, 0x00, 0x18,
+			},
+			Instruction: Instruction{
+				Opcode:  LookupSwitch,
+				Target:  20,
+				Keys:    []int32{-10},
+				Targets: []int{24},
+				Size:    20,
+			},
+			String: "0: lookupswitch { -10: 24; default: 20 }",
+		},
+	} {
+		i, err := DecodeInstruction(test.Code, test.PC)
+		if err != nil {
+			t.Errorf("test %d: unexpected error: %s", n+1, err)
+		} else if !reflect.DeepEqual(i, test.Instruction) {
+			t.Errorf("test %d: expecting instruction %#v, got %#v", n+1, test.Instruction, i)
+		} else if s := i.String(); s != test.String {
+			t.Errorf("test %d: expecting string %q, got %q", n+1, test.String, s)
+		}
+	}
+}
+
+func TestDecodeErrors(t *testing.T) {
+	for n, test := range [...]struct {
+		Code []byte
+		Err  error
+		PC   int
+	}{
+		{[]byte{0xca}, ErrUnknownOpcode, 0},
+		{[]byte{0x00, 0xff}, ErrUnknownOpcode, 1},
+		{[]byte{0x10}, ErrTruncated, 0},
+		{[]byte{0x00, 0xb6, 0x00}, ErrTruncated, 1},
+		{[]byte{0xc4}, ErrTruncated, 0},
+		{[]byte{0xc4, 0x15, 0x00}, ErrTruncated, 0},
+		{[]byte{0xc4, 0x00}, ErrInvalidWide, 0},
+		{[]byte{0xc4, 0xa7, 0x00, 0x00}, ErrInvalidWide, 0},
+		{[]byte{0xb9, 0x00, 0x01, 0x01, 0x01}, ErrInvalidOperand, 0},
+		{[]byte{0xba, 0x00, 0x01, 0x00, 0x01}, ErrInvalidOperand, 0},
+		{[]byte{0xaa, 0x00, 0x00}, ErrTruncated, 0},
+		{[]byte{0xaa, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00}, ErrInvalidSwitch, 0},
+		{[]byte{0xaa, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x7f, 0xff, 0xff, 0xff}, ErrTruncated, 0},
+		{[]byte{0xab, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0xff, 0xff, 0xff, 0xff}, ErrInvalidSwitch, 0},
+		{[]byte{0xab, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00}, ErrTruncated, 0},
+	} {
+		_, err := Decode(test.Code)
+		var de *DecodeError
+		if !errors.As(err, &de) {
+			t.Errorf("test %d: expecting *DecodeError, got %v", n+1, err)
+		} else if de.Err != test.Err {
+			t.Errorf("test %d: expecting error %v, got %v", n+1, test.Err, de.Err)
+		} else if de.PC != test.PC {
+			t.Errorf("test %d: expecting pc %d, got %d", n+1, test.PC, de.PC)
+		}
+	}
+}
+
+func TestDecode(t *testing.T) {
+	is, err := Decode([]byte{0x2a, 0xb7, 0x00, 0x01, 0xa7, 0xff, 0xfc, 0xb1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []Instruction{
+		{PC: 0, Opcode: ALoad0, Size: 1},
+		{PC: 1, Opcode: InvokeSpecial, Index: 1, Size: 3},
+		{PC: 4, Opcode: Goto, Target: 0, Size: 3},
+		{PC: 7, Opcode: Return, Size: 1},
+	}
+	if !reflect.DeepEqual(is, expected) {
+		t.Errorf("expecting instructions %v, got %v", expected, is)
+	}
+}
+
+func TestOpcodeString(t *testing.T) {
+	for n, test := range [...]struct {
+		Opcode Opcode
+		String string
+	}{
+		{Nop, "nop"},
+		{AConstNull, "aconst_null"},
+		{Ldc2W, "ldc2_w"},
+		{IfACmpNe, "if_acmpne"},
+		{JsrW, "jsr_w"},
+		{Opcode(0xca), "opcode(202)"},
+	} {
+		if s := test.Opcode.String(); s != test.String {
+			t.Errorf("test %d: expecting %q, got %q", n+1, test.String, s)
+		}
+	}
+}
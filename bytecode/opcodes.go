@@ -0,0 +1,416 @@
+package bytecode
+
+type Opcode uint8
+
+const (
+	Nop             Opcode = 0
+	AConstNull      Opcode = 1
+	IConstM1        Opcode = 2
+	IConst0         Opcode = 3
+	IConst1         Opcode = 4
+	IConst2         Opcode = 5
+	IConst3         Opcode = 6
+	IConst4         Opcode = 7
+	IConst5         Opcode = 8
+	LConst0         Opcode = 9
+	LConst1         Opcode = 10
+	FConst0         Opcode = 11
+	FConst1         Opcode = 12
+	FConst2         Opcode = 13
+	DConst0         Opcode = 14
+	DConst1         Opcode = 15
+	BIPush          Opcode = 16
+	SIPush          Opcode = 17
+	Ldc             Opcode = 18
+	LdcW            Opcode = 19
+	Ldc2W           Opcode = 20
+	ILoad           Opcode = 21
+	LLoad           Opcode = 22
+	FLoad           Opcode = 23
+	DLoad           Opcode = 24
+	ALoad           Opcode = 25
+	ILoad0          Opcode = 26
+	ILoad1          Opcode = 27
+	ILoad2          Opcode = 28
+	ILoad3          Opcode = 29
+	LLoad0          Opcode = 30
+	LLoad1          Opcode = 31
+	LLoad2          Opcode = 32
+	LLoad3          Opcode = 33
+	FLoad0          Opcode = 34
+	FLoad1          Opcode = 35
+	FLoad2          Opcode = 36
+	FLoad3          Opcode = 37
+	DLoad0          Opcode = 38
+	DLoad1          Opcode = 39
+	DLoad2          Opcode = 40
+	DLoad3          Opcode = 41
+	ALoad0          Opcode = 42
+	ALoad1          Opcode = 43
+	ALoad2          Opcode = 44
+	ALoad3          Opcode = 45
+	IALoad          Opcode = 46
+	LALoad          Opcode = 47
+	FALoad          Opcode = 48
+	DALoad          Opcode = 49
+	AALoad          Opcode = 50
+	BALoad          Opcode = 51
+	CALoad          Opcode = 52
+	SALoad          Opcode = 53
+	IStore          Opcode = 54
+	LStore          Opcode = 55
+	FStore          Opcode = 56
+	DStore          Opcode = 57
+	AStore          Opcode = 58
+	IStore0         Opcode = 59
+	IStore1         Opcode = 60
+	IStore2         Opcode = 61
+	IStore3         Opcode = 62
+	LStore0         Opcode = 63
+	LStore1         Opcode = 64
+	LStore2         Opcode = 65
+	LStore3         Opcode = 66
+	FStore0         Opcode = 67
+	FStore1         Opcode = 68
+	FStore2         Opcode = 69
+	FStore3         Opcode = 70
+	DStore0         Opcode = 71
+	DStore1         Opcode = 72
+	DStore2         Opcode = 73
+	DStore3         Opcode = 74
+	AStore0         Opcode = 75
+	AStore1         Opcode = 76
+	AStore2         Opcode = 77
+	AStore3         Opcode = 78
+	IAStore         Opcode = 79
+	LAStore         Opcode = 80
+	FAStore         Opcode = 81
+	DAStore         Opcode = 82
+	AAStore         Opcode = 83
+	BAStore         Opcode = 84
+	CAStore         Opcode = 85
+	SAStore         Opcode = 86
+	Pop             Opcode = 87
+	Pop2            Opcode = 88
+	Dup             Opcode = 89
+	DupX1           Opcode = 90
+	DupX2           Opcode = 91
+	Dup2            Opcode = 92
+	Dup2X1          Opcode = 93
+	Dup2X2          Opcode = 94
+	Swap            Opcode = 95
+	IAdd            Opcode = 96
+	LAdd            Opcode = 97
+	FAdd            Opcode = 98
+	DAdd            Opcode = 99
+	ISub            Opcode = 100
+	LSub            Opcode = 101
+	FSub            Opcode = 102
+	DSub            Opcode = 103
+	IMul            Opcode = 104
+	LMul            Opcode = 105
+	FMul            Opcode = 106
+	DMul            Opcode = 107
+	IDiv            Opcode = 108
+	LDiv            Opcode = 109
+	FDiv            Opcode = 110
+	DDiv            Opcode = 111
+	IRem            Opcode = 112
+	LRem            Opcode = 113
+	FRem            Opcode = 114
+	DRem            Opcode = 115
+	INeg            Opcode = 116
+	LNeg            Opcode = 117
+	FNeg            Opcode = 118
+	DNeg            Opcode = 119
+	IShl            Opcode = 120
+	LShl            Opcode = 121
+	IShr            Opcode = 122
+	LShr            Opcode = 123
+	IUShr           Opcode = 124
+	LUShr           Opcode = 125
+	IAnd            Opcode = 126
+	LAnd            Opcode = 127
+	IOr             Opcode = 128
+	LOr             Opcode = 129
+	IXor            Opcode = 130
+	LXor            Opcode = 131
+	IInc            Opcode = 132
+	I2L             Opcode = 133
+	I2F             Opcode = 134
+	I2D             Opcode = 135
+	L2I             Opcode = 136
+	L2F             Opcode = 137
+	L2D             Opcode = 138
+	F2I             Opcode = 139
+	F2L             Opcode = 140
+	F2D             Opcode = 141
+	D2I             Opcode = 142
+	D2L             Opcode = 143
+	D2F             Opcode = 144
+	I2B             Opcode = 145
+	I2C             Opcode = 146
+	I2S             Opcode = 147
+	LCmp            Opcode = 148
+	FCmpL           Opcode = 149
+	FCmpG           Opcode = 150
+	DCmpL           Opcode = 151
+	DCmpG           Opcode = 152
+	IfEq            Opcode = 153
+	IfNe            Opcode = 154
+	IfLt            Opcode = 155
+	IfGe            Opcode = 156
+	IfGt            Opcode = 157
+	IfLe            Opcode = 158
+	IfICmpEq        Opcode = 159
+	IfICmpNe        Opcode = 160
+	IfICmpLt        Opcode = 161
+	IfICmpGe        Opcode = 162
+	IfICmpGt        Opcode = 163
+	IfICmpLe        Opcode = 164
+	IfACmpEq        Opcode = 165
+	IfACmpNe        Opcode = 166
+	Goto            Opcode = 167
+	Jsr             Opcode = 168
+	Ret             Opcode = 169
+	TableSwitch     Opcode = 170
+	LookupSwitch    Opcode = 171
+	IReturn         Opcode = 172
+	LReturn         Opcode = 173
+	FReturn         Opcode = 174
+	DReturn         Opcode = 175
+	AReturn         Opcode = 176
+	Return          Opcode = 177
+	GetStatic       Opcode = 178
+	PutStatic       Opcode = 179
+	GetField        Opcode = 180
+	PutField        Opcode = 181
+	InvokeVirtual   Opcode = 182
+	InvokeSpecial   Opcode = 183
+	InvokeStatic    Opcode = 184
+	InvokeInterface Opcode = 185
+	InvokeDynamic   Opcode = 186
+	New             Opcode = 187
+	NewArray        Opcode = 188
+	ANewArray       Opcode = 189
+	ArrayLength     Opcode = 190
+	AThrow          Opcode = 191
+	CheckCast       Opcode = 192
+	InstanceOf      Opcode = 193
+	MonitorEnter    Opcode = 194
+	MonitorExit     Opcode = 195
+	Wide            Opcode = 196
+	MultiANewArray  Opcode = 197
+	IfNull          Opcode = 198
+	IfNonNull       Opcode = 199
+	GotoW           Opcode = 200
+	JsrW            Opcode = 201
+)
+
+var opcodes = [256]struct {
+	name   string
+	format format
+}{
+	Nop:             {"nop", noOperands},
+	AConstNull:      {"aconst_null", noOperands},
+	IConstM1:        {"iconst_m1", noOperands},
+	IConst0:         {"iconst_0", noOperands},
+	IConst1:         {"iconst_1", noOperands},
+	IConst2:         {"iconst_2", noOperands},
+	IConst3:         {"iconst_3", noOperands},
+	IConst4:         {"iconst_4", noOperands},
+	IConst5:         {"iconst_5", noOperands},
+	LConst0:         {"lconst_0", noOperands},
+	LConst1:         {"lconst_1", noOperands},
+	FConst0:         {"fconst_0", noOperands},
+	FConst1:         {"fconst_1", noOperands},
+	FConst2:         {"fconst_2", noOperands},
+	DConst0:         {"dconst_0", noOperands},
+	DConst1:         {"dconst_1", noOperands},
+	BIPush:          {"bipush", byteValue},
+	SIPush:          {"sipush", shortValue},
+	Ldc:             {"ldc", constant1},
+	LdcW:            {"ldc_w", constant2},
+	Ldc2W:           {"ldc2_w", constant2},
+	ILoad:           {"iload", local},
+	LLoad:           {"lload", local},
+	FLoad:           {"fload", local},
+	DLoad:           {"dload", local},
+	ALoad:           {"aload", local},
+	ILoad0:          {"iload_0", implicit},
+	ILoad1:          {"iload_1", implicit},
+	ILoad2:          {"iload_2", implicit},
+	ILoad3:          {"iload_3", implicit},
+	LLoad0:          {"lload_0", implicit},
+	LLoad1:          {"lload_1", implicit},
+	LLoad2:          {"lload_2", implicit},
+	LLoad3:          {"lload_3", implicit},
+	FLoad0:          {"fload_0", implicit},
+	FLoad1:          {"fload_1", implicit},
+	FLoad2:          {"fload_2", implicit},
+	FLoad3:          {"fload_3", implicit},
+	DLoad0:          {"dload_0", implicit},
+	DLoad1:          {"dload_1", implicit},
+	DLoad2:          {"dload_2", implicit},
+	DLoad3:          {"dload_3", implicit},
+	ALoad0:          {"aload_0", implicit},
+	ALoad1:          {"aload_1", implicit},
+	ALoad2:          {"aload_2", implicit},
+	ALoad3:          {"aload_3", implicit},
+	IALoad:          {"iaload", noOperands},
+	LALoad:          {"laload", noOperands},
+	FALoad:          {"faload", noOperands},
+	DALoad:          {"daload", noOperands},
+	AALoad:          {"aaload", noOperands},
+	BALoad:          {"baload", noOperands},
+	CALoad:          {"caload", noOperands},
+	SALoad:          {"saload", noOperands},
+	IStore:          {"istore", local},
+	LStore:          {"lstore", local},
+	FStore:          {"fstore", local},
+	DStore:          {"dstore", local},
+	AStore:          {"astore", local},
+	IStore0:         {"istore_0", implicit},
+	IStore1:         {"istore_1", implicit},
+	IStore2:         {"istore_2", implicit},
+	IStore3:         {"istore_3", implicit},
+	LStore0:         {"lstore_0", implicit},
+	LStore1:         {"lstore_1", implicit},
+	LStore2:         {"lstore_2", implicit},
+	LStore3:         {"lstore_3", implicit},
+	FStore0:         {"fstore_0", implicit},
+	FStore1:         {"fstore_1", implicit},
+	FStore2:         {"fstore_2", implicit},
+	FStore3:         {"fstore_3", implicit},
+	DStore0:         {"dstore_0", implicit},
+	DStore1:         {"dstore_1", implicit},
+	DStore2:         {"dstore_2", implicit},
+	DStore3:         {"dstore_3", implicit},
+	AStore0:         {"astore_0", implicit},
+	AStore1:         {"astore_1", implicit},
+	AStore2:         {"astore_2", implicit},
+	AStore3:         {"astore_3", implicit},
+	IAStore:         {"iastore", noOperands},
+	LAStore:         {"lastore", noOperands},
+	FAStore:         {"fastore", noOperands},
+	DAStore:         {"dastore", noOperands},
+	AAStore:         {"aastore", noOperands},
+	BAStore:         {"bastore", noOperands},
+	CAStore:         {"castore", noOperands},
+	SAStore:         {"sastore", noOperands},
+	Pop:             {"pop", noOperands},
+	Pop2:            {"pop2", noOperands},
+	Dup:             {"dup", noOperands},
+	DupX1:           {"dup_x1", noOperands},
+	DupX2:           {"dup_x2", noOperands},
+	Dup2:            {"dup2", noOperands},
+	Dup2X1:          {"dup2_x1", noOperands},
+	Dup2X2:          {"dup2_x2", noOperands},
+	Swap:            {"swap", noOperands},
+	IAdd:            {"iadd", noOperands},
+	LAdd:            {"ladd", noOperands},
+	FAdd:            {"fadd", noOperands},
+	DAdd:            {"dadd", noOperands},
+	ISub:            {"isub", noOperands},
+	LSub:            {"lsub", noOperands},
+	FSub:            {"fsub", noOperands},
+	DSub:            {"dsub", noOperands},
+	IMul:            {"imul", noOperands},
+	LMul:            {"lmul", noOperands},
+	FMul:            {"fmul", noOperands},
+	DMul:            {"dmul", noOperands},
+	IDiv:            {"idiv", noOperands},
+	LDiv:            {"ldiv", noOperands},
+	FDiv:            {"fdiv", noOperands},
+	DDiv:            {"ddiv", noOperands},
+	IRem:            {"irem", noOperands},
+	LRem:            {"lrem", noOperands},
+	FRem:            {"frem", noOperands},
+	DRem:            {"drem", noOperands},
+	INeg:            {"ineg", noOperands},
+	LNeg:            {"lneg", noOperands},
+	FNeg:            {"fneg", noOperands},
+	DNeg:            {"dneg", noOperands},
+	IShl:            {"ishl", noOperands},
+	LShl:            {"lshl", noOperands},
+	IShr:            {"ishr", noOperands},
+	LShr:            {"lshr", noOperands},
+	IUShr:           {"iushr", noOperands},
+	LUShr:           {"lushr", noOperands},
+	IAnd:            {"iand", noOperands},
+	LAnd:            {"land", noOperands},
+	IOr:             {"ior", noOperands},
+	LOr:             {"lor", noOperands},
+	IXor:            {"ixor", noOperands},
+	LXor:            {"lxor", noOperands},
+	IInc:            {"iinc", iinc},
+	I2L:             {"i2l", noOperands},
+	I2F:             {"i2f", noOperands},
+	I2D:             {"i2d", noOperands},
+	L2I:             {"l2i", noOperands},
+	L2F:             {"l2f", noOperands},
+	L2D:             {"l2d", noOperands},
+	F2I:             {"f2i", noOperands},
+	F2L:             {"f2l", noOperands},
+	F2D:             {"f2d", noOperands},
+	D2I:             {"d2i", noOperands},
+	D2L:             {"d2l", noOperands},
+	D2F:             {"d2f", noOperands},
+	I2B:             {"i2b", noOperands},
+	I2C:             {"i2c", noOperands},
+	I2S:             {"i2s", noOperands},
+	LCmp:            {"lcmp", noOperands},
+	FCmpL:           {"fcmpl", noOperands},
+	FCmpG:           {"fcmpg", noOperands},
+	DCmpL:           {"dcmpl", noOperands},
+	DCmpG:           {"dcmpg", noOperands},
+	IfEq:            {"ifeq", branch2},
+	IfNe:            {"ifne", branch2},
+	IfLt:            {"iflt", branch2},
+	IfGe:            {"ifge", branch2},
+	IfGt:            {"ifgt", branch2},
+	IfLe:            {"ifle", branch2},
+	IfICmpEq:        {"if_icmpeq", branch2},
+	IfICmpNe:        {"if_icmpne", branch2},
+	IfICmpLt:        {"if_icmplt", branch2},
+	IfICmpGe:        {"if_icmpge", branch2},
+	IfICmpGt:        {"if_icmpgt", branch2},
+	IfICmpLe:        {"if_icmple", branch2},
+	IfACmpEq:        {"if_acmpeq", branch2},
+	IfACmpNe:        {"if_acmpne", branch2},
+	Goto:            {"goto", branch2},
+	Jsr:             {"jsr", branch2},
+	Ret:             {"ret", local},
+	TableSwitch:     {"tableswitch", tableSwitch},
+	LookupSwitch:    {"lookupswitch", lookupSwitch},
+	IReturn:         {"ireturn", noOperands},
+	LReturn:         {"lreturn", noOperands},
+	FReturn:         {"freturn", noOperands},
+	DReturn:         {"dreturn", noOperands},
+	AReturn:         {"areturn", noOperands},
+	Return:          {"return", noOperands},
+	GetStatic:       {"getstatic", constant2},
+	PutStatic:       {"putstatic", constant2},
+	GetField:        {"getfield", constant2},
+	PutField:        {"putfield", constant2},
+	InvokeVirtual:   {"invokevirtual", constant2},
+	InvokeSpecial:   {"invokespecial", constant2},
+	InvokeStatic:    {"invokestatic", constant2},
+	InvokeInterface: {"invokeinterface", invokeInterface},
+	InvokeDynamic:   {"invokedynamic", invokeDynamic},
+	New:             {"new", constant2},
+	NewArray:        {"newarray", newArray},
+	ANewArray:       {"anewarray", constant2},
+	ArrayLength:     {"arraylength", noOperands},
+	AThrow:          {"athrow", noOperands},
+	CheckCast:       {"checkcast", constant2},
+	InstanceOf:      {"instanceof", constant2},
+	MonitorEnter:    {"monitorenter", noOperands},
+	MonitorExit:     {"monitorexit", noOperands},
+	Wide:            {"wide", wide},
+	MultiANewArray:  {"multianewarray", multiANewArray},
+	IfNull:          {"ifnull", branch2},
+	IfNonNull:       {"ifnonnull", branch2},
+	GotoW:           {"goto_w", branch4},
+	JsrW:            {"jsr_w", branch4},
+}
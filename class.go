@@ -0,0 +1,62 @@
+package javaclass
+
+// Name returns the internal name of the class, such as java/lang/String.
+func (c *Class) Name() (string, error) {
+	return c.ClassName(c.ThisClass)
+}
+
+// SuperName returns the internal name of the superclass, or an empty string
+// for classes without one, such as java/lang/Object and module-info.
+func (c *Class) SuperName() (string, error) {
+	if c.SuperClass == 0 {
+		return "", nil
+	}
+	return c.ClassName(c.SuperClass)
+}
+
+// InterfaceNames returns the internal names of the direct superinterfaces of
+// the class.
+func (c *Class) InterfaceNames() ([]string, error) {
+	names := make([]string, len(c.Interfaces))
+	for n, i := range c.Interfaces {
+		var err error
+		if names[n], err = c.ClassName(i); err != nil {
+			return nil, err
+		}
+	}
+	return names, nil
+}
+
+// Field returns the first field with the given name.
+func (c *Class) Field(name string) (FieldInfo, bool) {
+	for _, f := range c.Fields {
+		if n, err := c.UTF8(f.NameIndex); err == nil && n == name {
+			return f, true
+		}
+	}
+	return FieldInfo{}, false
+}
+
+// Method returns the method with the given name and descriptor.
+func (c *Class) Method(name, descriptor string) (MethodInfo, bool) {
+	for _, m := range c.Methods {
+		if n, err := c.UTF8(m.NameIndex); err != nil || n != name {
+			continue
+		}
+		if d, err := c.UTF8(m.DescriptorIndex); err == nil && d == descriptor {
+			return m, true
+		}
+	}
+	return MethodInfo{}, false
+}
+
+// FindAttribute returns the first attribute of type T.
+func FindAttribute[T AttributeInfo](attributes []AttributeInfo) (T, bool) {
+	for _, attribute := range attributes {
+		if a, ok := attribute.(T); ok {
+			return a, true
+		}
+	}
+	var t T
+	return t, false
+}
@@ -0,0 +1,146 @@
+package javaclass
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"testing"
+
+	"vimagination.zapto.org/javaclass/bytecode"
+)
+
+func TestClassNames(t *testing.T) {
+	c := &Class{ConstantPool: []CPInfo{nil}}
+	c.ThisClass = c.addClass("A")
+	c.SuperClass = c.addClass("B")
+	c.Interfaces = []uint16{c.addClass("I"), c.addClass("J")}
+	if name, err := c.Name(); err != nil || name != "A" {
+		t.Errorf("expecting name \"A\", got %q (%v)", name, err)
+	}
+	if name, err := c.SuperName(); err != nil || name != "B" {
+		t.Errorf("expecting super name \"B\", got %q (%v)", name, err)
+	}
+	if names, err := c.InterfaceNames(); err != nil || !reflect.DeepEqual(names, []string{"I", "J"}) {
+		t.Errorf("expecting interface names [I J], got %q (%v)", names, err)
+	}
+	c.SuperClass = 0
+	if name, err := c.SuperName(); err != nil || name != "" {
+		t.Errorf("expecting no super name, got %q (%v)", name, err)
+	}
+	for n, test := range [...]struct {
+		Err      error
+		Expected *ConstantError
+	}{
+		{
+			Err:      func() error { c := *c; c.ThisClass = 1; _, err := c.Name(); return err }(),
+			Expected: &ConstantError{Index: 1, Tag: ConstantUTF8, Err: ErrInvalidConstantPoolType},
+		},
+		{
+			Err:      func() error { c := *c; c.SuperClass = 100; _, err := c.SuperName(); return err }(),
+			Expected: &ConstantError{Index: 100, Err: ErrInvalidConstantPoolIndex},
+		},
+		{
+			Err:      func() error { c := *c; c.Interfaces = []uint16{6, 3}; _, err := c.InterfaceNames(); return err }(),
+			Expected: &ConstantError{Index: 3, Tag: ConstantUTF8, Err: ErrInvalidConstantPoolType},
+		},
+	} {
+		var ce *ConstantError
+		if !errors.As(test.Err, &ce) {
+			t.Errorf("test %d: expecting *ConstantError, got %v", n+1, test.Err)
+		} else if !reflect.DeepEqual(ce, test.Expected) {
+			t.Errorf("test %d: expecting error %q, got %q", n+1, test.Expected, ce)
+		}
+	}
+}
+
+func TestClassMembers(t *testing.T) {
+	c := &Class{ConstantPool: []CPInfo{nil}}
+	c.ThisClass = c.addClass("A")
+	x, m := c.addUTF8("x"), c.addUTF8("m")
+	i, v, iv := c.addUTF8("I"), c.addUTF8("()V"), c.addUTF8("(I)V")
+	code := CodeAttribute{
+		MaxLocals:  2,
+		Code:       []byte{0x2a, 0xb1},
+		Attributes: []AttributeInfo{LineNumberTableAttribute{LineNumberTable: []LineNumber{{0, 1}}}},
+	}
+	signature := SignatureAttribute{SignatureIndex: iv}
+	c.Fields = []FieldInfo{
+		{NameIndex: 100, DescriptorIndex: i},
+		{NameIndex: x, DescriptorIndex: i},
+		{NameIndex: x, DescriptorIndex: v},
+	}
+	c.Methods = []MethodInfo{
+		{NameIndex: m, DescriptorIndex: 100},
+		{NameIndex: m, DescriptorIndex: v, Attributes: []AttributeInfo{code}},
+		{NameIndex: m, DescriptorIndex: iv, Attributes: []AttributeInfo{signature}},
+	}
+	if f, ok := c.Field("x"); !ok || !reflect.DeepEqual(f, c.Fields[1]) {
+		t.Errorf("expecting to find first field x, got %v", f)
+	}
+	if _, ok := c.Field("y"); ok {
+		t.Errorf("unexpectedly found field y")
+	}
+	if method, ok := c.Method("m", "(I)V"); !ok || !reflect.DeepEqual(method, c.Methods[2]) {
+		t.Errorf("expecting to find method m(I)V, got %v", method)
+	}
+	if _, ok := c.Method("m", "I"); ok {
+		t.Errorf("unexpectedly found method m with descriptor I")
+	}
+	if _, ok := c.Method("y", "()V"); ok {
+		t.Errorf("unexpectedly found method y()V")
+	}
+	method, ok := c.Method("m", "()V")
+	if !ok || !reflect.DeepEqual(method, c.Methods[1]) {
+		t.Fatalf("expecting to find method m()V, got %v", method)
+	}
+	found, ok := method.Code()
+	if !ok || !reflect.DeepEqual(found, code) {
+		t.Errorf("expecting to find Code attribute, got %v", found)
+	}
+	if is, err := found.Instructions(); err != nil || len(is) != 2 || is[0].Opcode != bytecode.ALoad0 || is[1].Opcode != bytecode.Return {
+		t.Errorf("expecting instructions aload_0, return, got %v (%v)", is, err)
+	}
+	if _, ok := c.Methods[2].Code(); ok {
+		t.Errorf("unexpectedly found Code attribute on method without code")
+	}
+	if _, ok := FindAttribute[LineNumberTableAttribute](found.Attributes); !ok {
+		t.Errorf("expecting to find LineNumberTable attribute")
+	}
+	if s, ok := FindAttribute[SignatureAttribute](c.Methods[2].Attributes); !ok || s != signature {
+		t.Errorf("expecting to find Signature attribute, got %v", s)
+	}
+	if _, ok := FindAttribute[SignatureAttribute](c.Methods[1].Attributes); ok {
+		t.Errorf("unexpectedly found Signature attribute")
+	}
+}
+
+func TestFileMemberLookup(t *testing.T) {
+	data, err := lazyClass().MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := Open(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := f.Field("b"); ok {
+		t.Errorf("unexpectedly found field b before loading")
+	}
+	field, err := f.FieldAt(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	method, err := f.MethodAt(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.Load(); err != nil {
+		t.Fatal(err)
+	}
+	if byName, ok := f.Field("b"); !ok || !reflect.DeepEqual(byName, field) {
+		t.Errorf("expecting field b to match field 1, got %v", byName)
+	}
+	if byName, ok := f.Method("m", "()V"); !ok || !reflect.DeepEqual(byName, method) {
+		t.Errorf("expecting method m()V to match method 0, got %v", byName)
+	}
+}
@@ -1,7 +1,11 @@
 package javaclass
 
 import (
+	"bytes"
+	"errors"
 	"io"
+	"strings"
+	"unicode/utf8"
 
 	"vimagination.zapto.org/byteio"
 )
@@ -21,19 +25,40 @@ const (
 	ConstantNameAndType        = 12
 	ConstantMethodHandle       = 15
 	ConstantMethodType         = 16
+	ConstantDynamic            = 17
 	ConstantInvokeDynamic      = 18
+	ConstantModule             = 19
+	ConstantPackage            = 20
+)
+
+const (
+	RefGetField         = 1
+	RefGetStatic        = 2
+	RefPutField         = 3
+	RefPutStatic        = 4
+	RefInvokeVirtual    = 5
+	RefInvokeStatic     = 6
+	RefInvokeSpecial    = 7
+	RefNewInvokeSpecial = 8
+	RefInvokeInterface  = 9
 )
 
 type CPInfo interface {
 	Type() int
 }
 
-func readConstantPool(r io.Reader) ([]CPInfo, error) {
-	br := byteio.BigEndianReader{Reader: r}
+func (d *decoder) readConstantPool(r io.Reader) ([]CPInfo, error) {
+	br := binaryReader{r}
 	constantPoolCount, _, err := br.ReadUint16()
 	if err != nil {
 		return nil, err
 	}
+	if constantPoolCount == 0 {
+		return nil, valueError(ErrInvalidConstantPoolCount, 0)
+	}
+	if err := reserve[CPInfo](d, r, int(constantPoolCount), 3); err != nil {
+		return nil, err
+	}
 	constantPool := make([]CPInfo, 1, constantPoolCount)
 	constantPool[0] = ConstantNullInfo{}
 	for i := uint16(1); i < constantPoolCount; i++ {
@@ -47,7 +72,7 @@ func readConstantPool(r io.Reader) ([]CPInfo, error) {
 		)
 		switch tag {
 		case ConstantUTF8:
-			cpInfo, err = readConstantUTF8(r)
+			cpInfo, err = d.readConstantUTF8(r)
 		case ConstantInteger:
 			cpInfo, err = readConstantInteger(r)
 		case ConstantFloat:
@@ -74,13 +99,22 @@ func readConstantPool(r io.Reader) ([]CPInfo, error) {
 			cpInfo, err = readConstantMethodHandle(r)
 		case ConstantMethodType:
 			cpInfo, err = readConstantMethodType(r)
+		case ConstantDynamic:
+			cpInfo, err = readConstantDynamic(r)
 		case ConstantInvokeDynamic:
 			cpInfo, err = readConstantInvokeDynamic(r)
+		case ConstantModule:
+			cpInfo, err = readConstantModule(r)
+		case ConstantPackage:
+			cpInfo, err = readConstantPackage(r)
 		default:
-			err = ErrUnknownConstantPoolTag{tag}
+			err = valueError(ErrUnknownConstantPoolTag{tag}, int64(tag))
 		}
 		if err != nil {
-			return nil, err
+			return nil, indexError(err, "constant_pool", int(i))
+		}
+		if addNull && i == constantPoolCount-1 {
+			return nil, indexError(valueError(ErrInvalidConstantPoolCount, int64(constantPoolCount)), "constant_pool", int(i))
 		}
 		constantPool = append(constantPool, cpInfo)
 		if addNull {
@@ -91,6 +125,69 @@ func readConstantPool(r io.Reader) ([]CPInfo, error) {
 	return constantPool, nil
 }
 
+func writeConstantPool(w io.Writer, constantPool []CPInfo) error {
+	if len(constantPool) == 0 {
+		constantPool = []CPInfo{ConstantNullInfo{}}
+	}
+	if err := writeLength(w, len(constantPool)); err != nil {
+		return err
+	}
+	bw := byteio.BigEndianWriter{Writer: w}
+	for _, cpInfo := range constantPool[1:] {
+		if _, ok := cpInfo.(ConstantNullInfo); ok {
+			continue
+		} else if cpInfo == nil {
+			return ErrInvalidConstantPoolType
+		}
+		if _, err := bw.WriteUint8(uint8(cpInfo.Type())); err != nil {
+			return err
+		}
+		var err error
+		switch cpInfo := cpInfo.(type) {
+		case ConstantUTF8Info:
+			err = writeConstantUTF8(w, cpInfo)
+		case ConstantIntegerInfo:
+			err = writeConstantInteger(w, cpInfo)
+		case ConstantFloatInfo:
+			err = writeConstantFloat(w, cpInfo)
+		case ConstantLongInfo:
+			err = writeConstantLong(w, cpInfo)
+		case ConstantDoubleInfo:
+			err = writeConstantDouble(w, cpInfo)
+		case ConstantClassInfo:
+			err = writeConstantClass(w, cpInfo)
+		case ConstantStringInfo:
+			err = writeConstantString(w, cpInfo)
+		case ConstantFieldRefInfo:
+			err = writeConstantFieldRef(w, cpInfo)
+		case ConstantMethodRefInfo:
+			err = writeConstantMethodRef(w, cpInfo)
+		case ConstantInterfaceMethodRefInfo:
+			err = writeConstantInterfaceMethodRef(w, cpInfo)
+		case ConstantNameAndTypeInfo:
+			err = writeConstantNameAndType(w, cpInfo)
+		case ConstantMethodHandleInfo:
+			err = writeConstantMethodHandle(w, cpInfo)
+		case ConstantMethodTypeInfo:
+			err = writeConstantMethodType(w, cpInfo)
+		case ConstantDynamicInfo:
+			err = writeConstantDynamic(w, cpInfo)
+		case ConstantInvokeDynamicInfo:
+			err = writeConstantInvokeDynamic(w, cpInfo)
+		case ConstantModuleInfo:
+			err = writeConstantModule(w, cpInfo)
+		case ConstantPackageInfo:
+			err = writeConstantPackage(w, cpInfo)
+		default:
+			err = ErrInvalidConstantPoolType
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 type ConstantNullInfo struct{}
 
 func (ConstantNullInfo) Type() int {
@@ -99,32 +196,68 @@ func (ConstantNullInfo) Type() int {
 
 type ConstantUTF8Info struct {
 	String string
+	raw    string
 }
 
-func readConstantUTF8(r io.Reader) (CPInfo, error) {
-	br := byteio.BigEndianReader{Reader: r}
+func (d *decoder) readConstantUTF8(r io.Reader) (CPInfo, error) {
+	br := binaryReader{r}
 	length, _, err := br.ReadUint16()
 	if err != nil {
 		return nil, err
 	}
-	toString := make([]byte, length)
-	_, err = br.Read(toString)
+	toString, err := d.readBytes(r, int(length))
+	if err != nil {
+		return nil, err
+	}
+	if str := byteString(toString); isPlainASCII(str) {
+		return ConstantUTF8Info{String: str}, nil
+	}
+	str, err := DecodeModifiedUTF8(toString)
 	if err != nil {
 		return nil, err
 	}
-	return ConstantUTF8Info{string(toString)}, nil
+	// Only unpaired surrogates, decoded as utf8.RuneError, have an encoding
+	// that EncodeModifiedUTF8 would not reproduce, so only then are the
+	// original bytes kept.
+	if strings.ContainsRune(str, utf8.RuneError) && !bytes.Equal(EncodeModifiedUTF8(str), toString) {
+		return ConstantUTF8Info{String: str, raw: byteString(toString)}, nil
+	}
+	return ConstantUTF8Info{String: str}, nil
 }
 
 func (ConstantUTF8Info) Type() int {
 	return ConstantUTF8
 }
 
+// Bytes returns the modified UTF-8 encoding of the string.
+//
+// For a constant that was read from a class file and has not had its String
+// changed, this will be the bytes as they were read, even when they are not
+// the encoding EncodeModifiedUTF8 would produce.
+func (c ConstantUTF8Info) Bytes() []byte {
+	if c.raw != "" {
+		if str, err := DecodeModifiedUTF8([]byte(c.raw)); err == nil && str == c.String {
+			return []byte(c.raw)
+		}
+	}
+	return EncodeModifiedUTF8(c.String)
+}
+
+func writeConstantUTF8(w io.Writer, c ConstantUTF8Info) error {
+	data := c.Bytes()
+	if err := writeLength(w, len(data)); err != nil {
+		return err
+	}
+	_, err := w.Write(data)
+	return err
+}
+
 type ConstantIntegerInfo struct {
 	Integer uint32
 }
 
 func readConstantInteger(r io.Reader) (CPInfo, error) {
-	br := byteio.BigEndianReader{Reader: r}
+	br := binaryReader{r}
 	n, _, err := br.ReadUint32()
 	if err != nil {
 		return nil, err
@@ -136,12 +269,18 @@ func (ConstantIntegerInfo) Type() int {
 	return ConstantInteger
 }
 
+func writeConstantInteger(w io.Writer, c ConstantIntegerInfo) error {
+	bw := byteio.BigEndianWriter{Writer: w}
+	_, err := bw.WriteUint32(c.Integer)
+	return err
+}
+
 type ConstantFloatInfo struct {
 	Float float32
 }
 
 func readConstantFloat(r io.Reader) (CPInfo, error) {
-	br := byteio.BigEndianReader{Reader: r}
+	br := binaryReader{r}
 	n, _, err := br.ReadFloat32()
 	if err != nil {
 		return nil, err
@@ -153,12 +292,18 @@ func (ConstantFloatInfo) Type() int {
 	return ConstantFloat
 }
 
+func writeConstantFloat(w io.Writer, c ConstantFloatInfo) error {
+	bw := byteio.BigEndianWriter{Writer: w}
+	_, err := bw.WriteFloat32(c.Float)
+	return err
+}
+
 type ConstantLongInfo struct {
 	Long uint64
 }
 
 func readConstantLong(r io.Reader) (CPInfo, error) {
-	br := byteio.BigEndianReader{Reader: r}
+	br := binaryReader{r}
 	n, _, err := br.ReadUint64()
 	if err != nil {
 		return nil, err
@@ -170,12 +315,18 @@ func (ConstantLongInfo) Type() int {
 	return ConstantLong
 }
 
+func writeConstantLong(w io.Writer, c ConstantLongInfo) error {
+	bw := byteio.BigEndianWriter{Writer: w}
+	_, err := bw.WriteUint64(c.Long)
+	return err
+}
+
 type ConstantDoubleInfo struct {
 	Double float64
 }
 
 func readConstantDouble(r io.Reader) (CPInfo, error) {
-	br := byteio.BigEndianReader{Reader: r}
+	br := binaryReader{r}
 	n, _, err := br.ReadFloat64()
 	if err != nil {
 		return nil, err
@@ -187,12 +338,18 @@ func (ConstantDoubleInfo) Type() int {
 	return ConstantDouble
 }
 
+func writeConstantDouble(w io.Writer, c ConstantDoubleInfo) error {
+	bw := byteio.BigEndianWriter{Writer: w}
+	_, err := bw.WriteFloat64(c.Double)
+	return err
+}
+
 type ConstantClassInfo struct {
 	NameIndex uint16
 }
 
 func readConstantClass(r io.Reader) (CPInfo, error) {
-	br := byteio.BigEndianReader{Reader: r}
+	br := binaryReader{r}
 	n, _, err := br.ReadUint16()
 	if err != nil {
 		return nil, err
@@ -204,12 +361,18 @@ func (ConstantClassInfo) Type() int {
 	return ConstantClass
 }
 
+func writeConstantClass(w io.Writer, c ConstantClassInfo) error {
+	bw := byteio.BigEndianWriter{Writer: w}
+	_, err := bw.WriteUint16(c.NameIndex)
+	return err
+}
+
 type ConstantStringInfo struct {
 	StringIndex uint16
 }
 
 func readConstantString(r io.Reader) (CPInfo, error) {
-	br := byteio.BigEndianReader{Reader: r}
+	br := binaryReader{r}
 	n, _, err := br.ReadUint16()
 	if err != nil {
 		return nil, err
@@ -221,12 +384,18 @@ func (ConstantStringInfo) Type() int {
 	return ConstantString
 }
 
+func writeConstantString(w io.Writer, c ConstantStringInfo) error {
+	bw := byteio.BigEndianWriter{Writer: w}
+	_, err := bw.WriteUint16(c.StringIndex)
+	return err
+}
+
 type ConstantFieldRefInfo struct {
 	ClassIndex, NameAndTypeIndex uint16
 }
 
 func readConstantFieldRef(r io.Reader) (CPInfo, error) {
-	br := byteio.BigEndianReader{Reader: r}
+	br := binaryReader{r}
 	i, _, err := br.ReadUint16()
 	if err != nil {
 		return nil, err
@@ -242,12 +411,21 @@ func (ConstantFieldRefInfo) Type() int {
 	return ConstantFieldRef
 }
 
+func writeConstantFieldRef(w io.Writer, c ConstantFieldRefInfo) error {
+	bw := byteio.BigEndianWriter{Writer: w}
+	if _, err := bw.WriteUint16(c.ClassIndex); err != nil {
+		return err
+	}
+	_, err := bw.WriteUint16(c.NameAndTypeIndex)
+	return err
+}
+
 type ConstantMethodRefInfo struct {
 	ClassIndex, NameAndTypeIndex uint16
 }
 
 func readConstantMethodRef(r io.Reader) (CPInfo, error) {
-	br := byteio.BigEndianReader{Reader: r}
+	br := binaryReader{r}
 	i, _, err := br.ReadUint16()
 	if err != nil {
 		return nil, err
@@ -263,12 +441,21 @@ func (ConstantMethodRefInfo) Type() int {
 	return ConstantMethodRef
 }
 
+func writeConstantMethodRef(w io.Writer, c ConstantMethodRefInfo) error {
+	bw := byteio.BigEndianWriter{Writer: w}
+	if _, err := bw.WriteUint16(c.ClassIndex); err != nil {
+		return err
+	}
+	_, err := bw.WriteUint16(c.NameAndTypeIndex)
+	return err
+}
+
 type ConstantInterfaceMethodRefInfo struct {
 	ClassIndex, NameAndTypeIndex uint16
 }
 
 func readConstantInterfaceMethodRef(r io.Reader) (CPInfo, error) {
-	br := byteio.BigEndianReader{Reader: r}
+	br := binaryReader{r}
 	i, _, err := br.ReadUint16()
 	if err != nil {
 		return nil, err
@@ -284,12 +471,21 @@ func (ConstantInterfaceMethodRefInfo) Type() int {
 	return ConstantInterfaceMethodRef
 }
 
+func writeConstantInterfaceMethodRef(w io.Writer, c ConstantInterfaceMethodRefInfo) error {
+	bw := byteio.BigEndianWriter{Writer: w}
+	if _, err := bw.WriteUint16(c.ClassIndex); err != nil {
+		return err
+	}
+	_, err := bw.WriteUint16(c.NameAndTypeIndex)
+	return err
+}
+
 type ConstantNameAndTypeInfo struct {
 	NameIndex, DescriptorIndex uint16
 }
 
 func readConstantNameAndType(r io.Reader) (CPInfo, error) {
-	br := byteio.BigEndianReader{Reader: r}
+	br := binaryReader{r}
 	n, _, err := br.ReadUint16()
 	if err != nil {
 		return nil, err
@@ -305,13 +501,22 @@ func (ConstantNameAndTypeInfo) Type() int {
 	return ConstantNameAndType
 }
 
+func writeConstantNameAndType(w io.Writer, c ConstantNameAndTypeInfo) error {
+	bw := byteio.BigEndianWriter{Writer: w}
+	if _, err := bw.WriteUint16(c.NameIndex); err != nil {
+		return err
+	}
+	_, err := bw.WriteUint16(c.DescriptorIndex)
+	return err
+}
+
 type ConstantMethodHandleInfo struct {
 	ReferenceKind  uint8
 	ReferenceIndex uint16
 }
 
 func readConstantMethodHandle(r io.Reader) (CPInfo, error) {
-	br := byteio.BigEndianReader{Reader: r}
+	br := binaryReader{r}
 	k, _, err := br.ReadUint8()
 	if err != nil {
 		return nil, err
@@ -327,12 +532,21 @@ func (ConstantMethodHandleInfo) Type() int {
 	return ConstantMethodHandle
 }
 
+func writeConstantMethodHandle(w io.Writer, c ConstantMethodHandleInfo) error {
+	bw := byteio.BigEndianWriter{Writer: w}
+	if _, err := bw.WriteUint8(c.ReferenceKind); err != nil {
+		return err
+	}
+	_, err := bw.WriteUint16(c.ReferenceIndex)
+	return err
+}
+
 type ConstantMethodTypeInfo struct {
 	DescriptorIndex uint16
 }
 
 func readConstantMethodType(r io.Reader) (CPInfo, error) {
-	br := byteio.BigEndianReader{Reader: r}
+	br := binaryReader{r}
 	i, _, err := br.ReadUint16()
 	if err != nil {
 		return nil, err
@@ -344,12 +558,48 @@ func (ConstantMethodTypeInfo) Type() int {
 	return ConstantMethodType
 }
 
+func writeConstantMethodType(w io.Writer, c ConstantMethodTypeInfo) error {
+	bw := byteio.BigEndianWriter{Writer: w}
+	_, err := bw.WriteUint16(c.DescriptorIndex)
+	return err
+}
+
+type ConstantDynamicInfo struct {
+	BootstrapMethodAttrIndex, NameAndTypeIndex uint16
+}
+
+func readConstantDynamic(r io.Reader) (CPInfo, error) {
+	br := binaryReader{r}
+	b, _, err := br.ReadUint16()
+	if err != nil {
+		return nil, err
+	}
+	i, _, err := br.ReadUint16()
+	if err != nil {
+		return nil, err
+	}
+	return ConstantDynamicInfo{b, i}, nil
+}
+
+func (ConstantDynamicInfo) Type() int {
+	return ConstantDynamic
+}
+
+func writeConstantDynamic(w io.Writer, c ConstantDynamicInfo) error {
+	bw := byteio.BigEndianWriter{Writer: w}
+	if _, err := bw.WriteUint16(c.BootstrapMethodAttrIndex); err != nil {
+		return err
+	}
+	_, err := bw.WriteUint16(c.NameAndTypeIndex)
+	return err
+}
+
 type ConstantInvokeDynamicInfo struct {
 	BootstrapMethodAttrIndex, NameAndTypeIndex uint16
 }
 
 func readConstantInvokeDynamic(r io.Reader) (CPInfo, error) {
-	br := byteio.BigEndianReader{Reader: r}
+	br := binaryReader{r}
 	b, _, err := br.ReadUint16()
 	if err != nil {
 		return nil, err
@@ -365,6 +615,61 @@ func (ConstantInvokeDynamicInfo) Type() int {
 	return ConstantInvokeDynamic
 }
 
+func writeConstantInvokeDynamic(w io.Writer, c ConstantInvokeDynamicInfo) error {
+	bw := byteio.BigEndianWriter{Writer: w}
+	if _, err := bw.WriteUint16(c.BootstrapMethodAttrIndex); err != nil {
+		return err
+	}
+	_, err := bw.WriteUint16(c.NameAndTypeIndex)
+	return err
+}
+
+type ConstantModuleInfo struct {
+	NameIndex uint16
+}
+
+func readConstantModule(r io.Reader) (CPInfo, error) {
+	br := binaryReader{r}
+	n, _, err := br.ReadUint16()
+	if err != nil {
+		return nil, err
+	}
+	return ConstantModuleInfo{n}, nil
+}
+
+func (ConstantModuleInfo) Type() int {
+	return ConstantModule
+}
+
+func writeConstantModule(w io.Writer, c ConstantModuleInfo) error {
+	bw := byteio.BigEndianWriter{Writer: w}
+	_, err := bw.WriteUint16(c.NameIndex)
+	return err
+}
+
+type ConstantPackageInfo struct {
+	NameIndex uint16
+}
+
+func readConstantPackage(r io.Reader) (CPInfo, error) {
+	br := binaryReader{r}
+	n, _, err := br.ReadUint16()
+	if err != nil {
+		return nil, err
+	}
+	return ConstantPackageInfo{n}, nil
+}
+
+func (ConstantPackageInfo) Type() int {
+	return ConstantPackage
+}
+
+func writeConstantPackage(w io.Writer, c ConstantPackageInfo) error {
+	bw := byteio.BigEndianWriter{Writer: w}
+	_, err := bw.WriteUint16(c.NameIndex)
+	return err
+}
+
 // Error types
 
 type ErrUnknownConstantPoolTag struct {
@@ -374,3 +679,7 @@ type ErrUnknownConstantPoolTag struct {
 func (ErrUnknownConstantPoolTag) Error() string {
 	return "unknown constant pool tag"
 }
+
+//Errors
+
+var ErrInvalidConstantPoolCount = errors.New("invalid constant pool count")
@@ -0,0 +1,265 @@
+// Package descriptor parses and formats the field and method descriptors of
+// the Java class file format.
+package descriptor // import "vimagination.zapto.org/javaclass/descriptor"
+
+import (
+	"errors"
+	"strings"
+)
+
+// Type is a field type: a BaseType, an ObjectType or an ArrayType.
+type Type interface {
+	// String returns the type in descriptor form.
+	String() string
+
+	// Source returns the type as it would be written in Java source.
+	Source() string
+
+	// Slots returns the number of local variable slots taken by a value of
+	// the type.
+	Slots() int
+}
+
+type BaseType byte
+
+const (
+	Byte    BaseType = 'B'
+	Char    BaseType = 'C'
+	Double  BaseType = 'D'
+	Float   BaseType = 'F'
+	Int     BaseType = 'I'
+	Long    BaseType = 'J'
+	Short   BaseType = 'S'
+	Boolean BaseType = 'Z'
+
+	// Void is only valid as the return type of a method.
+	Void BaseType = 'V'
+)
+
+var baseTypeNames = map[BaseType]string{
+	Byte:    "byte",
+	Char:    "char",
+	Double:  "double",
+	Float:   "float",
+	Int:     "int",
+	Long:    "long",
+	Short:   "short",
+	Boolean: "boolean",
+	Void:    "void",
+}
+
+func (b BaseType) String() string {
+	return string(b)
+}
+
+func (b BaseType) Source() string {
+	return baseTypeNames[b]
+}
+
+func (b BaseType) Slots() int {
+	switch b {
+	case Long, Double:
+		return 2
+	case Void:
+		return 0
+	}
+	return 1
+}
+
+// ObjectType is a class or interface type, named by its internal name, such
+// as java/lang/String.
+type ObjectType struct {
+	ClassName string
+}
+
+func (o ObjectType) String() string {
+	return "L" + o.ClassName + ";"
+}
+
+func (o ObjectType) Source() string {
+	return SourceName(o.ClassName)
+}
+
+func (ObjectType) Slots() int {
+	return 1
+}
+
+// ArrayType is an array with the given number of dimensions, whose Elem is
+// either a BaseType or an ObjectType.
+type ArrayType struct {
+	Dimensions int
+	Elem       Type
+}
+
+func (a ArrayType) String() string {
+	return strings.Repeat("[", a.Dimensions) + a.Elem.String()
+}
+
+func (a ArrayType) Source() string {
+	return a.Elem.Source() + strings.Repeat("[]", a.Dimensions)
+}
+
+func (ArrayType) Slots() int {
+	return 1
+}
+
+// Method is a parsed method descriptor.
+type Method struct {
+	Params []Type
+	Return Type
+}
+
+func (m Method) String() string {
+	var sb strings.Builder
+	sb.WriteByte('(')
+	for _, p := range m.Params {
+		sb.WriteString(p.String())
+	}
+	sb.WriteByte(')')
+	sb.WriteString(m.Return.String())
+	return sb.String()
+}
+
+// Source returns a Java source declaration of a method with the given name
+// and this descriptor, such as void main(java.lang.String[]).
+func (m Method) Source(name string) string {
+	var sb strings.Builder
+	sb.WriteString(m.Return.Source())
+	sb.WriteByte(' ')
+	sb.WriteString(name)
+	sb.WriteByte('(')
+	for n, p := range m.Params {
+		if n > 0 {
+			sb.WriteString(", ")
+		}
+		sb.WriteString(p.Source())
+	}
+	sb.WriteByte(')')
+	return sb.String()
+}
+
+// ArgumentSlots returns the number of local variable slots taken by the
+// parameters of the method, not including this.
+func (m Method) ArgumentSlots() int {
+	var slots int
+	for _, p := range m.Params {
+		slots += p.Slots()
+	}
+	return slots
+}
+
+// ParseFieldDescriptor parses a field descriptor, such as [Ljava/lang/String;.
+func ParseFieldDescriptor(s string) (Type, error) {
+	t, n, err := parseFieldType(s)
+	if err != nil {
+		return nil, err
+	} else if n != len(s) {
+		return nil, ErrInvalidFieldDescriptor
+	}
+	return t, nil
+}
+
+// ParseMethodDescriptor parses a method descriptor, such as
+// ([Ljava/lang/String;IJ)V.
+func ParseMethodDescriptor(s string) (Method, error) {
+	if len(s) == 0 || s[0] != '(' {
+		return Method{}, ErrInvalidMethodDescriptor
+	}
+	var m Method
+	pos := 1
+	for {
+		if pos == len(s) {
+			return Method{}, ErrInvalidMethodDescriptor
+		} else if s[pos] == ')' {
+			break
+		}
+		t, n, err := parseFieldType(s[pos:])
+		if err != nil {
+			return Method{}, ErrInvalidMethodDescriptor
+		}
+		m.Params = append(m.Params, t)
+		pos += n
+	}
+	if s[pos+1:] == "V" {
+		m.Return = Void
+	} else if t, err := ParseFieldDescriptor(s[pos+1:]); err != nil {
+		return Method{}, ErrInvalidMethodDescriptor
+	} else {
+		m.Return = t
+	}
+	return m, nil
+}
+
+func parseFieldType(s string) (Type, int, error) {
+	dims := 0
+	for dims < len(s) && s[dims] == '[' {
+		dims++
+	}
+	if dims > 255 {
+		return nil, 0, ErrInvalidFieldDescriptor
+	}
+	elem, n, err := parseElemType(s[dims:])
+	if err != nil {
+		return nil, 0, err
+	} else if dims == 0 {
+		return elem, n, nil
+	}
+	return ArrayType{
+		Dimensions: dims,
+		Elem:       elem,
+	}, dims + n, nil
+}
+
+func parseElemType(s string) (Type, int, error) {
+	if len(s) == 0 {
+		return nil, 0, ErrInvalidFieldDescriptor
+	}
+	switch b := BaseType(s[0]); b {
+	case Byte, Char, Double, Float, Int, Long, Short, Boolean:
+		return b, 1, nil
+	case 'L':
+		end := strings.IndexByte(s, ';')
+		if end < 0 || !validInternalName(s[1:end]) {
+			return nil, 0, ErrInvalidFieldDescriptor
+		}
+		return ObjectType{ClassName: s[1:end]}, end + 1, nil
+	}
+	return nil, 0, ErrInvalidFieldDescriptor
+}
+
+func validInternalName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, part := range strings.Split(name, "/") {
+		if part == "" || strings.ContainsAny(part, ".;[") {
+			return false
+		}
+	}
+	return true
+}
+
+// BinaryName converts an internal name, such as java/util/Map$Entry, to a
+// binary name, such as java.util.Map$Entry.
+func BinaryName(internal string) string {
+	return strings.ReplaceAll(internal, "/", ".")
+}
+
+// InternalName converts a binary name to an internal name.
+func InternalName(binary string) string {
+	return strings.ReplaceAll(binary, ".", "/")
+}
+
+// SourceName converts an internal name to the name used in Java source, such
+// as java.util.Map.Entry, treating each $ as separating the name of a nested
+// class from that of its enclosing class.
+func SourceName(internal string) string {
+	return strings.NewReplacer("/", ".", "$", ".").Replace(internal)
+}
+
+//Errors
+
+var (
+	ErrInvalidFieldDescriptor  = errors.New("invalid field descriptor")
+	ErrInvalidMethodDescriptor = errors.New("invalid method descriptor")
+)
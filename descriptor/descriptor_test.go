@@ -0,0 +1,110 @@
+package descriptor
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseFieldDescriptor(t *testing.T) {
+	for n, test := range [...]struct {
+		Input  string
+		Type   Type
+		Source string
+		Slots  int
+		Err    error
+	}{
+		{"I", Int, "int", 1, nil},
+		{"J", Long, "long", 2, nil},
+		{"D", Double, "double", 2, nil},
+		{"Ljava/lang/String;", ObjectType{"java/lang/String"}, "java.lang.String", 1, nil},
+		{"Ljava/util/Map$Entry;", ObjectType{"java/util/Map$Entry"}, "java.util.Map.Entry", 1, nil},
+		{"[J", ArrayType{1, Long}, "long[]", 1, nil},
+		{"[[Ljava/lang/Object;", ArrayType{2, ObjectType{"java/lang/Object"}}, "java.lang.Object[][]", 1, nil},
+		{"", nil, "", 0, ErrInvalidFieldDescriptor},
+		{"V", nil, "", 0, ErrInvalidFieldDescriptor},
+		{"II", nil, "", 0, ErrInvalidFieldDescriptor},
+		{"[", nil, "", 0, ErrInvalidFieldDescriptor},
+		{"L;", nil, "", 0, ErrInvalidFieldDescriptor},
+		{"Ljava/lang/String", nil, "", 0, ErrInvalidFieldDescriptor},
+		{"Ljava.lang.String;", nil, "", 0, ErrInvalidFieldDescriptor},
+		{"Ljava//String;", nil, "", 0, ErrInvalidFieldDescriptor},
+		{strings.Repeat("[", 255) + "I", ArrayType{255, Int}, "int" + strings.Repeat("[]", 255), 1, nil},
+		{strings.Repeat("[", 256) + "I", nil, "", 0, ErrInvalidFieldDescriptor},
+	} {
+		typ, err := ParseFieldDescriptor(test.Input)
+		if err != test.Err {
+			t.Errorf("test %d: expecting error %v, got %v", n+1, test.Err, err)
+		} else if !reflect.DeepEqual(typ, test.Type) {
+			t.Errorf("test %d: expecting type %#v, got %#v", n+1, test.Type, typ)
+		} else if err == nil {
+			if s := typ.String(); s != test.Input {
+				t.Errorf("test %d: expecting descriptor %q, got %q", n+1, test.Input, s)
+			}
+			if s := typ.Source(); s != test.Source {
+				t.Errorf("test %d: expecting source %q, got %q", n+1, test.Source, s)
+			}
+			if s := typ.Slots(); s != test.Slots {
+				t.Errorf("test %d: expecting %d slots, got %d", n+1, test.Slots, s)
+			}
+		}
+	}
+}
+
+func TestParseMethodDescriptor(t *testing.T) {
+	for n, test := range [...]struct {
+		Input  string
+		Method Method
+		Source string
+		Slots  int
+		Err    error
+	}{
+		{"()V", Method{Return: Void}, "void m()", 0, nil},
+		{"([Ljava/lang/String;IJ)V", Method{[]Type{ArrayType{1, ObjectType{"java/lang/String"}}, Int, Long}, Void}, "void m(java.lang.String[], int, long)", 4, nil},
+		{"(D[D)Ljava/lang/Object;", Method{[]Type{Double, ArrayType{1, Double}}, ObjectType{"java/lang/Object"}}, "java.lang.Object m(double, double[])", 3, nil},
+		{"", Method{}, "", 0, ErrInvalidMethodDescriptor},
+		{"V", Method{}, "", 0, ErrInvalidMethodDescriptor},
+		{"(", Method{}, "", 0, ErrInvalidMethodDescriptor},
+		{"()", Method{}, "", 0, ErrInvalidMethodDescriptor},
+		{"(V)V", Method{}, "", 0, ErrInvalidMethodDescriptor},
+		{"(I)VV", Method{}, "", 0, ErrInvalidMethodDescriptor},
+		{"(Ljava/lang/String)V", Method{}, "", 0, ErrInvalidMethodDescriptor},
+	} {
+		m, err := ParseMethodDescriptor(test.Input)
+		if err != test.Err {
+			t.Errorf("test %d: expecting error %v, got %v", n+1, test.Err, err)
+		} else if !reflect.DeepEqual(m, test.Method) {
+			t.Errorf("test %d: expecting method %#v, got %#v", n+1, test.Method, m)
+		} else if err == nil {
+			if s := m.String(); s != test.Input {
+				t.Errorf("test %d: expecting descriptor %q, got %q", n+1, test.Input, s)
+			}
+			if s := m.Source("m"); s != test.Source {
+				t.Errorf("test %d: expecting source %q, got %q", n+1, test.Source, s)
+			}
+			if s := m.ArgumentSlots(); s != test.Slots {
+				t.Errorf("test %d: expecting %d slots, got %d", n+1, test.Slots, s)
+			}
+		}
+	}
+}
+
+func TestNames(t *testing.T) {
+	for n, test := range [...]struct {
+		Internal, Binary, Source string
+	}{
+		{"Foo", "Foo", "Foo"},
+		{"java/lang/String", "java.lang.String", "java.lang.String"},
+		{"java/util/Map$Entry", "java.util.Map$Entry", "java.util.Map.Entry"},
+	} {
+		if b := BinaryName(test.Internal); b != test.Binary {
+			t.Errorf("test %d: expecting binary name %q, got %q", n+1, test.Binary, b)
+		}
+		if i := InternalName(test.Binary); i != test.Internal {
+			t.Errorf("test %d: expecting internal name %q, got %q", n+1, test.Internal, i)
+		}
+		if s := SourceName(test.Internal); s != test.Source {
+			t.Errorf("test %d: expecting source name %q, got %q", n+1, test.Source, s)
+		}
+	}
+}
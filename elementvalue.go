@@ -27,8 +27,12 @@ type ElementValue interface {
 	Tag() uint8
 }
 
-func readElementValue(r io.Reader) (ElementValue, error) {
-	br := byteio.BigEndianReader{Reader: r}
+func (d *decoder) readElementValue(r io.Reader) (ElementValue, error) {
+	if err := d.enter(); err != nil {
+		return nil, err
+	}
+	defer d.leave()
+	br := binaryReader{r}
 	tag, _, err := br.ReadUint8()
 	if err != nil {
 		return nil, err
@@ -45,11 +49,11 @@ func readElementValue(r io.Reader) (ElementValue, error) {
 	case EVClass:
 		elementValue, err = readEVClass(r)
 	case EVAnnotationType:
-		elementValue, err = readEVAnnotationType(r)
+		elementValue, err = d.readEVAnnotationType(r)
 	case EVArray:
-		elementValue, err = readEVArray(r)
+		elementValue, err = d.readEVArray(r)
 	default:
-		err = ErrUnknownElementValueTag
+		err = valueError(ErrUnknownElementValueTag, int64(tag))
 	}
 	if err != nil {
 		return nil, err
@@ -57,13 +61,50 @@ func readElementValue(r io.Reader) (ElementValue, error) {
 	return elementValue, nil
 }
 
+func writeElementValue(w io.Writer, elementValue ElementValue) error {
+	if elementValue == nil {
+		return ErrUnknownElementValueTag
+	}
+	bw := byteio.BigEndianWriter{Writer: w}
+	if _, err := bw.WriteUint8(elementValue.Tag()); err != nil {
+		return err
+	}
+	switch ev := elementValue.(type) {
+	case ConstValueIndex:
+		_, err := bw.WriteUint16(ev.Index)
+		return err
+	case EnumConstValue:
+		if _, err := bw.WriteUint16(ev.TypeNameIndex); err != nil {
+			return err
+		}
+		_, err := bw.WriteUint16(ev.ConstNameIndex)
+		return err
+	case ClassInfoIndex:
+		_, err := bw.WriteUint16(ev.Index)
+		return err
+	case AnnotationValue:
+		return writeAnnotation(w, ev.Annotation)
+	case ArrayValue:
+		if err := writeLength(w, len(ev.ArrayValues)); err != nil {
+			return err
+		}
+		for _, arrayValue := range ev.ArrayValues {
+			if err := writeElementValue(w, arrayValue); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return ErrUnknownElementValueTag
+}
+
 type ConstValueIndex struct {
 	tag   uint8
 	Index uint16
 }
 
 func readEVConstValueIndex(r io.Reader) (ConstValueIndex, error) {
-	br := byteio.BigEndianReader{Reader: r}
+	br := binaryReader{r}
 	index, _, err := br.ReadUint16()
 	return ConstValueIndex{
 		Index: index,
@@ -79,7 +120,7 @@ type EnumConstValue struct {
 }
 
 func readEVEnumConstant(r io.Reader) (ElementValue, error) {
-	br := byteio.BigEndianReader{Reader: r}
+	br := binaryReader{r}
 	typeNameIndex, _, err := br.ReadUint16()
 	if err != nil {
 		return nil, err
@@ -103,7 +144,7 @@ type ClassInfoIndex struct {
 }
 
 func readEVClass(r io.Reader) (ElementValue, error) {
-	br := byteio.BigEndianReader{Reader: r}
+	br := binaryReader{r}
 	index, _, err := br.ReadUint16()
 	if err != nil {
 		return nil, err
@@ -119,8 +160,8 @@ type AnnotationValue struct {
 	Annotation Annotation
 }
 
-func readEVAnnotationType(r io.Reader) (ElementValue, error) {
-	annotation, err := readAnnotation(r)
+func (d *decoder) readEVAnnotationType(r io.Reader) (ElementValue, error) {
+	annotation, err := d.readAnnotation(r)
 	if err != nil {
 		return nil, err
 	}
@@ -139,17 +180,20 @@ func (ArrayValue) Tag() uint8 {
 	return EVArray
 }
 
-func readEVArray(r io.Reader) (ElementValue, error) {
-	br := byteio.BigEndianReader{Reader: r}
+func (d *decoder) readEVArray(r io.Reader) (ElementValue, error) {
+	br := binaryReader{r}
 	numValues, _, err := br.ReadUint16()
 	if err != nil {
 		return nil, err
 	}
+	if err := reserve[ElementValue](d, r, int(numValues), 3); err != nil {
+		return nil, err
+	}
 	arrayValues := make([]ElementValue, numValues)
 	for i := uint16(0); i < numValues; i++ {
-		arrayValues[i], err = readElementValue(r)
+		arrayValues[i], err = d.readElementValue(r)
 		if err != nil {
-			return nil, err
+			return nil, indexError(err, "values", int(i))
 		}
 
 	}
@@ -0,0 +1,82 @@
+package javaclass
+
+import (
+	"io"
+	"strconv"
+)
+
+// ParseError is returned by Read when a class file cannot be decoded.
+//
+// Offset is the number of bytes of the class file that had been read when the
+// error was detected, and Path describes the structure being read, such as
+// methods[12].Code.StackMapTable.entries[3]. Value holds the offending tag or
+// index, or -1 when there is none.
+type ParseError struct {
+	Err    error
+	Offset int64
+	Path   string
+	Value  int64
+}
+
+func (p *ParseError) Error() string {
+	s := p.Err.Error()
+	if p.Path != "" {
+		s = p.Path + ": " + s
+	}
+	if p.Value >= 0 {
+		s += " (value " + strconv.FormatInt(p.Value, 10) + ")"
+	}
+	return s + " at offset " + strconv.FormatInt(p.Offset, 10)
+}
+
+func (p *ParseError) Unwrap() error {
+	return p.Err
+}
+
+func toParseError(err error) *ParseError {
+	if p, ok := err.(*ParseError); ok {
+		return p
+	}
+	return &ParseError{
+		Err:   err,
+		Value: -1,
+	}
+}
+
+func pathError(err error, segment string) error {
+	p := toParseError(err)
+	if p.Path == "" {
+		p.Path = segment
+	} else {
+		p.Path = segment + "." + p.Path
+	}
+	return p
+}
+
+func indexError(err error, name string, index int) error {
+	return pathError(err, name+"["+strconv.Itoa(index)+"]")
+}
+
+func valueError(err error, value int64) error {
+	p := toParseError(err)
+	p.Value = value
+	return p
+}
+
+func parseError(err error, offset int64) error {
+	p := toParseError(err)
+	p.Offset = offset
+	return p
+}
+
+type countReader struct {
+	io.Reader
+	n   int64
+	buf [8]byte
+}
+
+func (c *countReader) Read(p []byte) (int, error) {
+	n, err := c.Reader.Read(p)
+	c.n += int64(n)
+	return n, err
+}
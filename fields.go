@@ -7,40 +7,73 @@ import (
 )
 
 type FieldInfo struct {
-	AccessFlags, NameIndex, DescriptorIndex uint16
-	Attributes                              []AttributeInfo
+	AccessFlags                FieldAccess
+	NameIndex, DescriptorIndex uint16
+	Attributes                 []AttributeInfo
 }
 
-func (c *Class) readFields(r io.Reader) ([]FieldInfo, error) {
-	br := byteio.BigEndianReader{Reader: r}
+func (d *decoder) readFields(r io.Reader) ([]FieldInfo, error) {
+	br := binaryReader{r}
 	fieldsCount, _, err := br.ReadUint16()
 	if err != nil {
 		return nil, err
 	}
+	if err := reserve[FieldInfo](d, r, int(fieldsCount), 8); err != nil {
+		return nil, err
+	}
 	fields := make([]FieldInfo, fieldsCount)
 	for i := uint16(0); i < fieldsCount; i++ {
-		af, _, err := br.ReadUint16()
-		if err != nil {
-			return nil, err
+		if fields[i], err = d.readField(r); err != nil {
+			return nil, indexError(err, "fields", int(i))
 		}
-		ni, _, err := br.ReadUint16()
-		if err != nil {
-			return nil, err
+	}
+	return fields, nil
+}
+
+func (d *decoder) readField(r io.Reader) (FieldInfo, error) {
+	br := binaryReader{r}
+	af, _, err := br.ReadUint16()
+	if err != nil {
+		return FieldInfo{}, err
+	}
+	ni, _, err := br.ReadUint16()
+	if err != nil {
+		return FieldInfo{}, err
+	}
+	di, _, err := br.ReadUint16()
+	if err != nil {
+		return FieldInfo{}, err
+	}
+	attributes, err := d.readAttributes(r, locationField)
+	if err != nil {
+		return FieldInfo{}, err
+	}
+	return FieldInfo{
+		AccessFlags:     FieldAccess(af),
+		NameIndex:       ni,
+		DescriptorIndex: di,
+		Attributes:      attributes,
+	}, nil
+}
+
+func (e *encoder) writeFields(w io.Writer, fields []FieldInfo) error {
+	if err := writeLength(w, len(fields)); err != nil {
+		return err
+	}
+	bw := byteio.BigEndianWriter{Writer: w}
+	for _, f := range fields {
+		if _, err := bw.WriteUint16(uint16(f.AccessFlags)); err != nil {
+			return err
 		}
-		di, _, err := br.ReadUint16()
-		if err != nil {
-			return nil, err
+		if _, err := bw.WriteUint16(f.NameIndex); err != nil {
+			return err
 		}
-		attributes, err := c.readAttributes(r)
-		if err != nil {
-			return nil, err
+		if _, err := bw.WriteUint16(f.DescriptorIndex); err != nil {
+			return err
 		}
-		fields[i] = FieldInfo{
-			AccessFlags:     af,
-			NameIndex:       ni,
-			DescriptorIndex: di,
-			Attributes:      attributes,
+		if err := e.writeAttributes(w, f.Attributes); err != nil {
+			return err
 		}
 	}
-	return fields, nil
+	return nil
 }
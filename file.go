@@ -0,0 +1,245 @@
+package javaclass
+
+import (
+	"bufio"
+	"errors"
+	"io"
+)
+
+// File is a class file opened for random access.
+//
+// Open decodes the header, constant pool and interfaces, and records where
+// each field and method, and the class attributes, can be found. These are
+// then decoded when first requested, with Load filling in the Fields, Methods
+// and Attributes of the embedded Class.
+//
+// A File is not safe for concurrent use.
+type File struct {
+	*Class
+	d          decoder
+	r          io.ReaderAt
+	fields     []span
+	methods    []span
+	attributes span
+	fieldInfo  []FieldInfo
+	methodInfo []MethodInfo
+	attrInfo   []AttributeInfo
+}
+
+type span struct {
+	offset, length int64
+	loaded         bool
+}
+
+// Open opens a class file of the given size using the default ReadOptions.
+func Open(r io.ReaderAt, size int64) (*File, error) {
+	return ReadOptions{}.Open(r, size)
+}
+
+// Open opens a class file of the given size, which must be exactly the size
+// of the class file.
+//
+// Errors in the class file are returned as a *ParseError, both by Open and by
+// the methods of the returned File.
+func (o ReadOptions) Open(r io.ReaderAt, size int64) (*File, error) {
+	f := &File{
+		Class: new(Class),
+		r:     r,
+	}
+	f.d = decoder{
+		Class:       f.Class,
+		ReadOptions: o,
+	}
+	s := scanReader{
+		r:    r,
+		size: size,
+		buf:  bufio.NewReader(io.NewSectionReader(r, 0, size)),
+	}
+	err := f.d.readHeader(&s)
+	if err == nil {
+		if f.fields, err = s.scanMembers(&f.d, "fields"); err == nil {
+			if f.methods, err = s.scanMembers(&f.d, "methods"); err == nil {
+				f.attributes.offset = s.offset
+				err = s.skipAttributes()
+				f.attributes.length = s.offset - f.attributes.offset
+				if err == nil && s.offset != size {
+					err = ErrTrailingData
+				}
+			}
+		}
+	}
+	if err != nil {
+		return nil, parseError(err, s.offset)
+	}
+	f.fieldInfo = make([]FieldInfo, len(f.fields))
+	f.methodInfo = make([]MethodInfo, len(f.methods))
+	return f, nil
+}
+
+func (f *File) NumFields() int {
+	return len(f.fields)
+}
+
+// FieldAt decodes, if not already decoded, and returns the field at index i.
+//
+// Fields can be found by name, using the Field method of the embedded Class,
+// once the File has been loaded.
+func (f *File) FieldAt(i int) (FieldInfo, error) {
+	if i < 0 || i >= len(f.fields) {
+		return FieldInfo{}, ErrMemberIndex
+	}
+	if err := f.decode(&f.fields[i], func(r io.Reader) (err error) {
+		if f.fieldInfo[i], err = f.d.readField(r); err != nil {
+			return indexError(err, "fields", i)
+		}
+		return nil
+	}); err != nil {
+		return FieldInfo{}, err
+	}
+	return f.fieldInfo[i], nil
+}
+
+func (f *File) NumMethods() int {
+	return len(f.methods)
+}
+
+// MethodAt decodes, if not already decoded, and returns the method at index i.
+//
+// Methods can be found by name and descriptor, using the Method method of the
+// embedded Class, once the File has been loaded.
+func (f *File) MethodAt(i int) (MethodInfo, error) {
+	if i < 0 || i >= len(f.methods) {
+		return MethodInfo{}, ErrMemberIndex
+	}
+	if err := f.decode(&f.methods[i], func(r io.Reader) (err error) {
+		if f.methodInfo[i], err = f.d.readMethod(r); err != nil {
+			return indexError(err, "methods", i)
+		}
+		return nil
+	}); err != nil {
+		return MethodInfo{}, err
+	}
+	return f.methodInfo[i], nil
+}
+
+func (f *File) ClassAttributes() ([]AttributeInfo, error) {
+	if err := f.decode(&f.attributes, func(r io.Reader) (err error) {
+		f.attrInfo, err = f.d.readAttributes(r, locationClass)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+	return f.attrInfo, nil
+}
+
+// Load decodes everything not yet decoded and returns the complete Class.
+func (f *File) Load() (*Class, error) {
+	for i := range f.fields {
+		if _, err := f.FieldAt(i); err != nil {
+			return nil, err
+		}
+	}
+	for i := range f.methods {
+		if _, err := f.MethodAt(i); err != nil {
+			return nil, err
+		}
+	}
+	attributes, err := f.ClassAttributes()
+	if err != nil {
+		return nil, err
+	}
+	f.Fields = f.fieldInfo
+	f.Methods = f.methodInfo
+	f.Attributes = attributes
+	return f.Class, nil
+}
+
+func (f *File) decode(s *span, fn func(io.Reader) error) error {
+	if s.loaded {
+		return nil
+	}
+	cr := countReader{Reader: bufio.NewReader(io.NewSectionReader(f.r, s.offset, s.length))}
+	if err := fn(&cr); err != nil {
+		return parseError(err, s.offset+cr.n)
+	}
+	s.loaded = true
+	return nil
+}
+
+// scanReader reads sequentially from an io.ReaderAt, seeking past skipped
+// data instead of reading it.
+type scanReader struct {
+	r      io.ReaderAt
+	size   int64
+	offset int64
+	buf    *bufio.Reader
+	tmp    [8]byte
+}
+
+func (s *scanReader) Read(p []byte) (int, error) {
+	n, err := s.buf.Read(p)
+	s.offset += int64(n)
+	return n, err
+}
+
+func (s *scanReader) skip(n int64) error {
+	if n > s.size-s.offset {
+		s.offset = s.size
+		return io.ErrUnexpectedEOF
+	}
+	if n <= int64(s.buf.Buffered()) {
+		s.buf.Discard(int(n))
+	} else {
+		s.buf.Reset(io.NewSectionReader(s.r, s.offset+n, s.size-s.offset-n))
+	}
+	s.offset += n
+	return nil
+}
+
+func (s *scanReader) scanMembers(d *decoder, name string) ([]span, error) {
+	br := binaryReader{s}
+	count, _, err := br.ReadUint16()
+	if err != nil {
+		return nil, err
+	}
+	if err := reserve[span](d, nil, int(count), 0); err != nil {
+		return nil, err
+	}
+	spans := make([]span, count)
+	for i := range spans {
+		spans[i].offset = s.offset
+		if err := s.skip(6); err != nil {
+			return nil, indexError(err, name, i)
+		}
+		if err := s.skipAttributes(); err != nil {
+			return nil, indexError(err, name, i)
+		}
+		spans[i].length = s.offset - spans[i].offset
+	}
+	return spans, nil
+}
+
+func (s *scanReader) skipAttributes() error {
+	br := binaryReader{s}
+	count, _, err := br.ReadUint16()
+	if err != nil {
+		return err
+	}
+	for i := uint16(0); i < count; i++ {
+		if err := s.skip(2); err != nil {
+			return indexError(err, "attributes", int(i))
+		}
+		length, _, err := br.ReadUint32()
+		if err != nil {
+			return indexError(err, "attributes", int(i))
+		}
+		if err := s.skip(int64(length)); err != nil {
+			return indexError(err, "attributes", int(i))
+		}
+	}
+	return nil
+}
+
+//Errors
+
+var ErrMemberIndex = errors.New("member index out of range")
@@ -0,0 +1,182 @@
+package javaclass
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+type countingReaderAt struct {
+	*bytes.Reader
+	read int
+}
+
+func (c *countingReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	n, err := c.Reader.ReadAt(p, off)
+	c.read += n
+	return n, err
+}
+
+func TestOpen(t *testing.T) {
+	for n, seed := range seedClassFiles() {
+		expected, err := Read(bytes.NewReader(seed))
+		if err != nil {
+			t.Fatalf("test %d: unexpected error reading class: %s", n+1, err)
+		}
+		f, err := Open(bytes.NewReader(seed), int64(len(seed)))
+		if err != nil {
+			t.Errorf("test %d: unexpected error opening class: %s", n+1, err)
+			continue
+		}
+		if f.NumFields() != len(expected.Fields) || f.NumMethods() != len(expected.Methods) {
+			t.Errorf("test %d: expecting %d fields and %d methods, got %d and %d", n+1, len(expected.Fields), len(expected.Methods), f.NumFields(), f.NumMethods())
+		}
+		if _, err := f.MethodAt(f.NumMethods()); !errors.Is(err, ErrMemberIndex) {
+			t.Errorf("test %d: expecting error %s, got %v", n+1, ErrMemberIndex, err)
+		}
+		c, err := f.Load()
+		if err != nil {
+			t.Errorf("test %d: unexpected error loading class: %s", n+1, err)
+		} else if !reflect.DeepEqual(c, expected) {
+			t.Errorf("test %d: loaded class differs from read class", n+1)
+		}
+		for i := range seed {
+			_, readErr := Read(bytes.NewReader(seed[:i]))
+			f, err := Open(bytes.NewReader(seed[:i]), int64(i))
+			if err == nil {
+				_, err = f.Load()
+			}
+			var pe *ParseError
+			if (err == nil) != (readErr == nil) || err != nil && !errors.As(err, &pe) {
+				t.Errorf("test %d: opening %d bytes: expecting error like %q, got %q", n+1, i, readErr, err)
+			}
+		}
+	}
+}
+
+// lazyClass returns a class with two fields, the first carrying a large
+// attribute, a method with code, and a SourceFile attribute.
+func lazyClass() *Class {
+	c := &Class{ConstantPool: []CPInfo{nil}, Major: 52}
+	c.ThisClass = c.addClass("A")
+	c.SuperClass = c.addClass("java/lang/Object")
+	for _, name := range [...]string{AttrCode, AttrSourceFile, "Custom"} {
+		c.addUTF8(name)
+	}
+	c.Fields = []FieldInfo{
+		{
+			NameIndex:       c.addUTF8("a"),
+			DescriptorIndex: c.addUTF8("I"),
+			Attributes:      []AttributeInfo{UnknownAttribute{AttributeName: "Custom", Data: make([]byte, 1<<16)}},
+		},
+		{NameIndex: c.addUTF8("b"), DescriptorIndex: c.addUTF8("J")},
+	}
+	c.Methods = []MethodInfo{
+		{
+			NameIndex:       c.addUTF8("m"),
+			DescriptorIndex: c.addUTF8("()V"),
+			Attributes:      []AttributeInfo{CodeAttribute{MaxLocals: 1, Code: []byte{0xb1}}},
+		},
+	}
+	c.Attributes = []AttributeInfo{SourceFileAttribute{SourceFileIndex: c.addUTF8("A.java")}}
+	return c
+}
+
+func TestOpenLazy(t *testing.T) {
+	data, err := lazyClass().MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := Parse(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := &countingReaderAt{Reader: bytes.NewReader(data)}
+	f, err := Open(r, int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.NumFields() != 2 || f.NumMethods() != 1 {
+		t.Errorf("expecting 2 fields and 1 method, got %d and %d", f.NumFields(), f.NumMethods())
+	}
+	if name, err := f.Name(); err != nil || name != "A" {
+		t.Errorf("expecting name \"A\", got %q (%v)", name, err)
+	}
+	method, err := f.MethodAt(0)
+	if err != nil {
+		t.Fatal(err)
+	} else if !reflect.DeepEqual(method, c.Methods[0]) {
+		t.Errorf("method differs from parsed method")
+	}
+	if attributes, err := f.ClassAttributes(); err != nil {
+		t.Fatal(err)
+	} else if !reflect.DeepEqual(attributes, c.Attributes) {
+		t.Errorf("class attributes differ from parsed attributes")
+	}
+	if field, err := f.FieldAt(1); err != nil {
+		t.Fatal(err)
+	} else if !reflect.DeepEqual(field, c.Fields[1]) {
+		t.Errorf("field differs from parsed field")
+	}
+	if r.read >= 1<<16 {
+		t.Errorf("expecting skipped field attribute not to be read, read %d of %d bytes", r.read, len(data))
+	}
+	if field, err := f.FieldAt(0); err != nil {
+		t.Fatal(err)
+	} else if !reflect.DeepEqual(field, c.Fields[0]) {
+		t.Errorf("field differs from parsed field")
+	}
+	for n, err := range [...]error{
+		func() error { _, err := f.FieldAt(-1); return err }(),
+		func() error { _, err := f.FieldAt(2); return err }(),
+		func() error { _, err := f.MethodAt(-1); return err }(),
+		func() error { _, err := f.MethodAt(1); return err }(),
+	} {
+		if err != ErrMemberIndex {
+			t.Errorf("test %d: expecting error %v, got %v", n+1, ErrMemberIndex, err)
+		}
+	}
+	if loaded, err := f.Load(); err != nil {
+		t.Errorf("unexpected error loading class: %s", err)
+	} else if !reflect.DeepEqual(loaded, c) {
+		t.Errorf("loaded class differs from parsed class")
+	}
+}
+
+func TestFileMemberErrors(t *testing.T) {
+	c := lazyClass()
+	c.Fields[0].Attributes = nil
+	c.addUTF8(AttrSignature)
+	c.Fields[1].Attributes = []AttributeInfo{UnknownAttribute{AttributeName: AttrSignature, Data: []byte{0}}}
+	c.Methods[0].Attributes = []AttributeInfo{UnknownAttribute{AttributeName: AttrCode, Data: []byte{0, 1, 0, 1, 0, 0, 0, 2, 0xb1}}}
+	data, err := c.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := Open(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.FieldAt(0); err != nil {
+		t.Errorf("unexpected error reading valid field: %s", err)
+	}
+	for n, test := range [...]struct {
+		Err  error
+		Path string
+	}{
+		{func() error { _, err := f.FieldAt(1); return err }(), "fields[1].Signature"},
+		{func() error { _, err := f.MethodAt(0); return err }(), "methods[0].Code"},
+		{func() error { _, err := f.Load(); return err }(), "fields[1].Signature"},
+	} {
+		var pe *ParseError
+		if !errors.As(test.Err, &pe) {
+			t.Errorf("test %d: expecting *ParseError, got %v", n+1, test.Err)
+		} else if pe.Path != test.Path {
+			t.Errorf("test %d: expecting error path %q, got %q", n+1, test.Path, pe.Path)
+		}
+	}
+	if _, err := f.ClassAttributes(); err != nil {
+		t.Errorf("unexpected error reading class attributes: %s", err)
+	}
+}
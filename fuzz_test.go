@@ -0,0 +1,461 @@
+package javaclass
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"reflect"
+	"runtime"
+	"testing"
+)
+
+var fuzzOptions = ReadOptions{MaxAllocation: 1 << 20}
+
+func FuzzRead(f *testing.F) {
+	for _, seed := range seedClassFiles() {
+		f.Add(seed)
+		f.Add(cat(seed, u1(0)))
+	}
+	f.Fuzz(func(t *testing.T, data []byte) {
+		r := bytes.NewReader(data)
+		c, err := fuzzOptions.Read(r)
+		var pe *ParseError
+		if err != nil && !errors.As(err, &pe) {
+			t.Fatalf("expecting *ParseError, got %T: %s", err, err)
+		}
+		sizedErr := err
+		if err == nil && r.Len() > 0 {
+			sizedErr = &ParseError{Err: ErrTrailingData, Offset: int64(len(data) - r.Len()), Value: -1}
+		}
+		o := fuzzOptions
+		o.AliasInput = true
+		if _, perr := o.Parse(data); fmt.Sprint(perr) != fmt.Sprint(sizedErr) {
+			t.Fatalf("expecting Parse to return error %q, got %q", sizedErr, perr)
+		}
+		f, oerr := fuzzOptions.Open(bytes.NewReader(data), int64(len(data)))
+		if oerr == nil {
+			_, oerr = f.Load()
+		}
+		if (oerr == nil) != (sizedErr == nil) {
+			t.Fatalf("expecting Open to return error %v, got %v", sizedErr, oerr)
+		}
+		var b ClassBuilder
+		if aerr := fuzzOptions.Accept(bytes.NewReader(data), &b); aerr != nil && err == nil {
+			t.Fatalf("Read succeeded, Accept returned %v", aerr)
+		} else if err == nil && !reflect.DeepEqual(b.Class(), c) {
+			t.Fatalf("class built by Accept differs from read class")
+		}
+	})
+}
+
+// TestReadAllocation checks that reading the seeds, and copies of them with
+// each pair of bytes replaced by a large count, allocates little more than the
+// MaxAllocation limit. Measuring allocations stops the world, so this is not
+// done for every input by FuzzRead.
+func TestReadAllocation(t *testing.T) {
+	for n, seed := range seedClassFiles() {
+		for i := -1; i < len(seed)-1; i++ {
+			data := seed
+			if i >= 0 {
+				data = append([]byte{}, seed...)
+				data[i], data[i+1] = 0xff, 0xff
+			}
+			var before, after runtime.MemStats
+			runtime.ReadMemStats(&before)
+			fuzzOptions.Read(bytes.NewReader(data))
+			runtime.ReadMemStats(&after)
+			if alloc, limit := after.TotalAlloc-before.TotalAlloc, uint64(fuzzOptions.MaxAllocation)+256*uint64(len(data))+1<<20; alloc > limit {
+				t.Errorf("test %d: allocated %d bytes reading seed changed at %d, expecting no more than %d", n+1, alloc, i, limit)
+			}
+		}
+	}
+}
+
+func FuzzRoundTrip(f *testing.F) {
+	for _, seed := range seedClassFiles() {
+		f.Add(seed)
+	}
+	f.Add(duplicateNamesClass())
+	f.Fuzz(func(t *testing.T, data []byte) {
+		c, err := fuzzOptions.Parse(data)
+		if err != nil {
+			return
+		}
+		written, err := c.MarshalBinary()
+		if err != nil {
+			t.Fatalf("unexpected error writing class: %s", err)
+		}
+		if !bytes.Equal(data, written) {
+			t.Fatalf("written class differs from input:\n%x\n%x", data, written)
+		}
+	})
+}
+
+func TestSeedClassFiles(t *testing.T) {
+	for n, seed := range seedClassFiles() {
+		c, err := Read(bytes.NewReader(seed))
+		if err != nil {
+			t.Errorf("test %d: unexpected error reading class: %s", n+1, err)
+			continue
+		}
+		if name := undecodedAttribute(c); name != "" {
+			t.Errorf("test %d: attribute %s was not decoded", n+1, name)
+		}
+		data, err := c.MarshalBinary()
+		if err != nil {
+			t.Errorf("test %d: unexpected error writing class: %s", n+1, err)
+		} else if !bytes.Equal(data, seed) {
+			t.Errorf("test %d: written class differs from seed:\n%x\n%x", n+1, seed, data)
+		}
+	}
+}
+
+func undecodedAttribute(c *Class) string {
+	for _, attribute := range allAttributes(c) {
+		if u, ok := attribute.(UnknownAttribute); ok && u.AttributeName != "Custom" {
+			return u.AttributeName
+		}
+	}
+	return ""
+}
+
+// allAttributes returns the attributes of c, its fields and methods, and the
+// Code and Record attributes among them.
+func allAttributes(c *Class) []AttributeInfo {
+	attributes := append([]AttributeInfo{}, c.Attributes...)
+	for _, field := range c.Fields {
+		attributes = append(attributes, field.Attributes...)
+	}
+	for _, method := range c.Methods {
+		attributes = append(attributes, method.Attributes...)
+	}
+	for i := 0; i < len(attributes); i++ {
+		switch a := attributes[i].(type) {
+		case CodeAttribute:
+			attributes = append(attributes, a.Attributes...)
+		case RecordAttribute:
+			for _, component := range a.Components {
+				attributes = append(attributes, component.Attributes...)
+			}
+		}
+	}
+	return attributes
+}
+
+// The seed class files are assembled by hand, rather than with WriteTo, so
+// that the differential fuzzer starts from input that is independent of the
+// writer.
+//
+// Between them they use every constant pool tag, every predefined attribute,
+// every stack map frame and verification type, every element value tag and
+// every type annotation target.
+
+const (
+	seedThis           = 2
+	seedObject         = 4
+	seedName           = 5
+	seedFieldType      = 6
+	seedMethodType     = 7
+	seedFieldNAT       = 8
+	seedMethodNAT      = 10
+	seedMethodRef      = 11
+	seedString         = 13
+	seedInteger        = 14
+	seedFloat          = 15
+	seedLong           = 16
+	seedDouble         = 18
+	seedMethodHandle   = 20
+	seedMethodTypeInfo = 21
+	seedModule         = 24
+	seedPackage        = 25
+	seedUTF8           = 26
+)
+
+var seedAttributeNames = []string{
+	AttrConstantValue, AttrCode, AttrStackMapTable, AttrExceptions,
+	AttrInnerClasses, AttrEnclosingMethod, AttrSynthetic, AttrSignature,
+	AttrSourceFile, AttrSourceDebugExtension, AttrLineNumberTable,
+	AttrLocalVariableTable, AttrLocalVariableTypeTable, AttrDeprecated,
+	AttrRuntimeVisibleAnnotations, AttrRuntimeInvisibleAnnotations,
+	AttrRuntimeVisibleParameterAnnotations,
+	AttrRuntimeInvisibleParameterAnnotations,
+	AttrRuntimeVisibleTypeAnnotations, AttrRuntimeInvisibleTypeAnnotations,
+	AttrAnnotationDefault, AttrBootstrapMethods, AttrModule,
+	AttrModulePackages, AttrModuleMainClass, AttrNestHost, AttrNestMembers,
+	AttrRecord, AttrPermittedSubclasses, AttrMethodParameters, "Custom",
+}
+
+func (c *Class) addUTF8(s string) uint16 {
+	c.ConstantPool = append(c.ConstantPool, ConstantUTF8Info{String: s})
+	return uint16(len(c.ConstantPool) - 1)
+}
+
+func (c *Class) addClass(name string) uint16 {
+	c.ConstantPool = append(c.ConstantPool, ConstantClassInfo{NameIndex: c.addUTF8(name)})
+	return uint16(len(c.ConstantPool) - 1)
+}
+
+func u1(v ...int) []byte {
+	b := make([]byte, len(v))
+	for n, x := range v {
+		b[n] = byte(x)
+	}
+	return b
+}
+
+func u2(v ...int) []byte {
+	b := make([]byte, 0, len(v)*2)
+	for _, x := range v {
+		b = append(b, byte(x>>8), byte(x))
+	}
+	return b
+}
+
+func u4(v ...int) []byte {
+	b := make([]byte, 0, len(v)*4)
+	for _, x := range v {
+		b = append(b, byte(x>>24), byte(x>>16), byte(x>>8), byte(x))
+	}
+	return b
+}
+
+func cat(parts ...[]byte) []byte {
+	var b []byte
+	for _, part := range parts {
+		b = append(b, part...)
+	}
+	return b
+}
+
+func list1(entries ...[]byte) []byte {
+	return cat(u1(len(entries)), cat(entries...))
+}
+
+func list2(entries ...[]byte) []byte {
+	return cat(u2(len(entries)), cat(entries...))
+}
+
+func utf8Constant(s string) []byte {
+	return cat(u1(ConstantUTF8), u2(len(s)), []byte(s))
+}
+
+func attribute(name string, body ...[]byte) []byte {
+	index := seedUTF8 + 1
+	for n, attributeName := range seedAttributeNames {
+		if attributeName == name {
+			index += n
+		}
+	}
+	b := cat(body...)
+	return cat(u2(index), u4(len(b)), b)
+}
+
+func seedClass(accessFlags, superClass int, interfaces, fields, methods, attributes []byte) []byte {
+	pool := [][]byte{
+		utf8Constant("Seed"),
+		cat(u1(ConstantClass), u2(1)),
+		utf8Constant("java/lang/Object"),
+		cat(u1(ConstantClass), u2(3)),
+		utf8Constant("x"),
+		utf8Constant("I"),
+		utf8Constant("()V"),
+		cat(u1(ConstantNameAndType), u2(seedName, seedFieldType)),
+		cat(u1(ConstantFieldRef), u2(seedThis, seedFieldNAT)),
+		cat(u1(ConstantNameAndType), u2(seedName, seedMethodType)),
+		cat(u1(ConstantMethodRef), u2(seedThis, seedMethodNAT)),
+		cat(u1(ConstantInterfaceMethodRef), u2(seedObject, seedMethodNAT)),
+		cat(u1(ConstantString), u2(1)),
+		cat(u1(ConstantInteger), u4(-2)),
+		cat(u1(ConstantFloat), u4(0x3fc00000)),
+		cat(u1(ConstantLong), u4(1, 2)),
+		cat(u1(ConstantDouble), u4(0x40020000, 0)),
+		cat(u1(ConstantMethodHandle), u1(RefInvokeStatic), u2(seedMethodRef)),
+		cat(u1(ConstantMethodType), u2(seedMethodType)),
+		cat(u1(ConstantDynamic), u2(0, seedFieldNAT)),
+		cat(u1(ConstantInvokeDynamic), u2(0, seedMethodNAT)),
+		cat(u1(ConstantModule), u2(1)),
+		cat(u1(ConstantPackage), u2(1)),
+		cat(u1(ConstantUTF8), u2(12), u1(0xc3, 0xa9, 0xc0, 0x80, 0xed, 0xa0, 0xbd, 0xed, 0xb8, 0x80, 'A', 'Z')),
+	}
+	for _, name := range seedAttributeNames {
+		pool = append(pool, utf8Constant(name))
+	}
+	return cat(
+		u4(Magic),
+		u2(0, 65),
+		u2(seedUTF8+len(seedAttributeNames)+1), cat(pool...),
+		u2(accessFlags, seedThis, superClass),
+		interfaces,
+		fields,
+		methods,
+		attributes,
+	)
+}
+
+func constElementValue(tag byte, index int) []byte {
+	return cat(u1(int(tag)), u2(index))
+}
+
+func seedAnnotation() []byte {
+	values := [][]byte{
+		constElementValue(EVByte, seedInteger),
+		constElementValue(EVChar, seedInteger),
+		constElementValue(EVDouble, seedDouble),
+		constElementValue(EVFloat, seedFloat),
+		constElementValue(EVInt, seedInteger),
+		constElementValue(EVLong, seedLong),
+		constElementValue(EVShort, seedInteger),
+		constElementValue(EVBoolean, seedInteger),
+		constElementValue(EVString, seedUTF8),
+		cat(u1(EVEnumConstant), u2(seedFieldType, seedName)),
+		cat(u1(EVClass), u2(seedFieldType)),
+		cat(u1(EVAnnotationType), u2(seedFieldType), list2()),
+		cat(u1(EVArray), list2(constElementValue(EVInt, seedInteger), cat(u1(EVArray), list2()))),
+	}
+	pairs := make([][]byte, len(values))
+	for n, value := range values {
+		pairs[n] = cat(u2(seedName), value)
+	}
+	return cat(u2(seedFieldType), list2(pairs...))
+}
+
+func seedTypeAnnotation(target ...[]byte) []byte {
+	return cat(
+		cat(target...),
+		list1(u1(TypePathArray, 0), u1(TypePathTypeArgument, 1)),
+		u2(seedFieldType),
+		list2(cat(u2(seedName), constElementValue(EVInt, seedInteger))),
+	)
+}
+
+func seedClassFiles() [][]byte {
+	annotation := seedAnnotation()
+	code := u1(0x2a, 0xb1)
+	codeAttribute := attribute(AttrCode,
+		u2(2, 3),
+		u4(len(code)), code,
+		list2(u2(0, 1, 1, seedObject)),
+		list2(
+			attribute(AttrLineNumberTable, list2(u2(0, 1))),
+			attribute(AttrLocalVariableTable, list2(u2(0, 2, seedName, seedFieldType, 1))),
+			attribute(AttrLocalVariableTypeTable, list2(u2(0, 2, seedName, seedFieldType, 1))),
+			attribute(AttrStackMapTable, list2(
+				u1(3),
+				u1(64+5, InfoIntegerVariableInfo),
+				cat(u1(FrameSameLocals1StackItemExtended), u2(300), u1(InfoObjectVariable), u2(seedObject)),
+				cat(u1(248), u2(1)),
+				cat(u1(FrameMaxChop), u2(1)),
+				cat(u1(FrameSameExtended), u2(2)),
+				cat(u1(252), u2(3), u1(InfoTopVariable)),
+				cat(u1(FrameMaxAppend), u2(3), u1(InfoFloatVariable, InfoDoubleVariable, InfoLongVariable)),
+				cat(u1(FrameFull), u2(4),
+					list2(u1(InfoTopVariable), u1(InfoIntegerVariableInfo), u1(InfoFloatVariable), u1(InfoDoubleVariable), u1(InfoLongVariable), u1(InfoNullVariable), u1(InfoUnitializedThisVariable), cat(u1(InfoObjectVariable), u2(seedObject)), cat(u1(InfoUnitializedVariable), u2(0))),
+					list2(u1(InfoIntegerVariableInfo)),
+				),
+			)),
+			attribute(AttrRuntimeVisibleTypeAnnotations, list2(
+				seedTypeAnnotation(u1(TargetLocalVariable), list2(u2(0, 2, 1))),
+				seedTypeAnnotation(u1(TargetResourceVariable), list2(u2(0, 2, 1), u2(1, 1, 2))),
+				seedTypeAnnotation(u1(TargetExceptionParameter), u2(0)),
+				seedTypeAnnotation(u1(TargetInstanceOf), u2(1)),
+				seedTypeAnnotation(u1(TargetNew), u2(1)),
+				seedTypeAnnotation(u1(TargetConstructorReference), u2(1)),
+				seedTypeAnnotation(u1(TargetMethodReference), u2(1)),
+				seedTypeAnnotation(u1(TargetCast), u2(1), u1(0)),
+				seedTypeAnnotation(u1(TargetConstructorInvocationTypeArgument), u2(1), u1(0)),
+				seedTypeAnnotation(u1(TargetMethodInvocationTypeArgument), u2(1), u1(0)),
+				seedTypeAnnotation(u1(TargetConstructorReferenceTypeArgument), u2(1), u1(0)),
+				seedTypeAnnotation(u1(TargetMethodReferenceTypeArgument), u2(1), u1(0)),
+			)),
+			attribute(AttrRuntimeInvisibleTypeAnnotations, list2()),
+		),
+	)
+	field := cat(
+		u2(0x0019, seedName, seedFieldType),
+		list2(
+			attribute(AttrConstantValue, u2(seedInteger)),
+			attribute(AttrSynthetic),
+			attribute(AttrDeprecated),
+			attribute(AttrSignature, u2(seedFieldType)),
+			attribute(AttrRuntimeVisibleAnnotations, list2(annotation)),
+			attribute(AttrRuntimeInvisibleAnnotations, list2()),
+			attribute(AttrRuntimeVisibleTypeAnnotations, list2(seedTypeAnnotation(u1(TargetField)))),
+			attribute(AttrRuntimeInvisibleTypeAnnotations, list2()),
+		),
+	)
+	method := cat(
+		u2(0x0001, seedName, seedMethodType),
+		list2(
+			codeAttribute,
+			attribute(AttrExceptions, list2(u2(seedObject))),
+			attribute(AttrSynthetic),
+			attribute(AttrDeprecated),
+			attribute(AttrSignature, u2(seedMethodType)),
+			attribute(AttrRuntimeVisibleAnnotations, list2()),
+			attribute(AttrRuntimeInvisibleAnnotations, list2(annotation)),
+			attribute(AttrRuntimeVisibleParameterAnnotations, list1(list2(annotation), list2())),
+			attribute(AttrRuntimeInvisibleParameterAnnotations, list1()),
+			attribute(AttrRuntimeVisibleTypeAnnotations, list2(
+				seedTypeAnnotation(u1(TargetMethodTypeParameter, 0)),
+				seedTypeAnnotation(u1(TargetMethodTypeParameterBound, 0, 1)),
+				seedTypeAnnotation(u1(TargetReturn)),
+				seedTypeAnnotation(u1(TargetReceiver)),
+				seedTypeAnnotation(u1(TargetFormalParameter, 0)),
+				seedTypeAnnotation(u1(TargetThrows), u2(0)),
+			)),
+			attribute(AttrRuntimeInvisibleTypeAnnotations, list2()),
+			attribute(AttrAnnotationDefault, cat(u1(EVAnnotationType), annotation)),
+			attribute(AttrMethodParameters, list1(u2(seedName, 0x0010), u2(0, 0))),
+		),
+	)
+	component := cat(
+		u2(seedName, seedFieldType),
+		list2(
+			attribute(AttrSignature, u2(seedFieldType)),
+			attribute(AttrRuntimeVisibleAnnotations, list2(annotation)),
+			attribute(AttrRuntimeInvisibleAnnotations, list2()),
+			attribute(AttrRuntimeVisibleTypeAnnotations, list2(seedTypeAnnotation(u1(TargetField)))),
+			attribute(AttrRuntimeInvisibleTypeAnnotations, list2()),
+		),
+	)
+	module := cat(
+		u2(seedModule, 0x0020, 1),
+		list2(u2(seedModule, 0x8000, 0)),
+		list2(cat(u2(seedPackage, 0), list2(u2(seedModule)))),
+		list2(cat(u2(seedPackage, 0), list2())),
+		list2(u2(seedObject)),
+		list2(cat(u2(seedObject), list2(u2(seedThis)))),
+	)
+	return [][]byte{
+		seedClass(0x0021, seedObject, list2(u2(seedObject)), list2(field), list2(method), list2(
+			attribute(AttrSourceFile, u2(1)),
+			attribute(AttrSourceDebugExtension, []byte("SMAP\n")),
+			attribute(AttrInnerClasses, list2(u2(seedThis, seedObject, 1, 0x0008))),
+			attribute(AttrEnclosingMethod, u2(seedObject, seedMethodNAT)),
+			attribute(AttrSynthetic),
+			attribute(AttrDeprecated),
+			attribute(AttrSignature, u2(1)),
+			attribute(AttrRuntimeVisibleAnnotations, list2(annotation)),
+			attribute(AttrRuntimeInvisibleAnnotations, list2()),
+			attribute(AttrRuntimeVisibleTypeAnnotations, list2(
+				seedTypeAnnotation(u1(TargetClassTypeParameter, 0)),
+				seedTypeAnnotation(u1(TargetSupertype), u2(0xffff)),
+				seedTypeAnnotation(u1(TargetClassTypeParameterBound, 0, 1)),
+			)),
+			attribute(AttrRuntimeInvisibleTypeAnnotations, list2()),
+			attribute(AttrBootstrapMethods, list2(cat(u2(seedMethodHandle), list2(u2(seedString), u2(seedMethodTypeInfo), u2(seedInteger))))),
+			attribute(AttrNestMembers, list2(u2(seedObject))),
+			attribute(AttrPermittedSubclasses, list2(u2(seedObject))),
+			attribute("Custom", u1(1, 2, 3)),
+		)),
+		seedClass(0x0031, seedObject, list2(), list2(), list2(), list2(
+			attribute(AttrRecord, list2(component)),
+			attribute(AttrNestHost, u2(seedObject)),
+		)),
+		seedClass(0x8000, 0, list2(), list2(), list2(), list2(
+			attribute(AttrModule, module),
+			attribute(AttrModulePackages, list2(u2(seedPackage))),
+			attribute(AttrModuleMainClass, u2(seedThis)),
+		)),
+	}
+}
@@ -1,8 +1,10 @@
 package javaclass // import "vimagination.zapto.org/javaclass"
 
 import (
+	"bytes"
 	"errors"
 	"io"
+	"math"
 
 	"vimagination.zapto.org/byteio"
 )
@@ -11,88 +13,209 @@ const Magic = 0xCAFEBABE
 
 var (
 	ErrInvalidMagic = errors.New("read invalid magic string")
+	ErrTooLarge     = errors.New("value too large to encode")
+	ErrTrailingData = errors.New("unexpected data after class file")
 )
 
 type Class struct {
-	Minor, Major                       uint16
-	ConstantPool                       []CPInfo
-	AccessFlags, ThisClass, SuperClass uint16
-	Interfaces                         []uint16
-	Fields                             []FieldInfo
-	Methods                            []MethodInfo
-	Attributes                         []AttributeInfo
+	Minor, Major          uint16
+	ConstantPool          []CPInfo
+	AccessFlags           ClassAccess
+	ThisClass, SuperClass uint16
+	Interfaces            []uint16
+	Fields                []FieldInfo
+	Methods               []MethodInfo
+	Attributes            []AttributeInfo
 }
 
+// Read decodes a class file from r using the default ReadOptions.
 func Read(r io.Reader) (*Class, error) {
-	br := byteio.BigEndianReader{Reader: r}
+	return ReadOptions{}.Read(r)
+}
+
+// Read decodes a class file from r. Reading stops at the end of the class
+// file, so r may hold further data, which is left unread.
+//
+// Errors are returned as a *ParseError.
+func (o ReadOptions) Read(r io.Reader) (*Class, error) {
+	cr := countReader{Reader: r}
+	d := decoder{
+		Class:       new(Class),
+		ReadOptions: o,
+	}
+	if err := d.read(&cr); err != nil {
+		return nil, parseError(err, cr.n)
+	}
+	return d.Class, nil
+}
+
+func (d *decoder) read(r io.Reader) error {
+	err := d.readHeader(r)
+	if err != nil {
+		return err
+	}
+
+	if d.Fields, err = d.readFields(r); err != nil {
+		return err
+	}
+
+	if d.Methods, err = d.readMethods(r); err != nil {
+		return err
+	}
+
+	d.Attributes, err = d.readAttributes(r, locationClass)
+
+	return err
+}
+
+// readHeader reads the parts of the class file that precede the fields.
+func (d *decoder) readHeader(r io.Reader) error {
+	br := binaryReader{r}
 	magic, _, err := br.ReadUint32()
 	if err != nil {
-		return nil, err
+		return err
 	}
 	if magic != Magic {
-		return nil, ErrInvalidMagic
+		return valueError(ErrInvalidMagic, int64(magic))
 	}
-	minor, _, err := br.ReadUint16()
-	if err != nil {
-		return nil, err
+	if d.Minor, _, err = br.ReadUint16(); err != nil {
+		return err
 	}
-	major, _, err := br.ReadUint16()
-	if err != nil {
-		return nil, err
+	if d.Major, _, err = br.ReadUint16(); err != nil {
+		return err
 	}
-	cp, err := readConstantPool(r)
-	if err != nil {
-		return nil, err
+	if d.ConstantPool, err = d.readConstantPool(r); err != nil {
+		return err
 	}
 	accessFlags, _, err := br.ReadUint16()
 	if err != nil {
-		return nil, err
+		return err
 	}
-	thisClass, _, err := br.ReadUint16()
-	if err != nil {
-		return nil, err
+	d.AccessFlags = ClassAccess(accessFlags)
+	if d.ThisClass, _, err = br.ReadUint16(); err != nil {
+		return err
 	}
-	superClass, _, err := br.ReadUint16()
-	if err != nil {
-		return nil, err
+	if d.SuperClass, _, err = br.ReadUint16(); err != nil {
+		return err
 	}
 
 	interfacesCount, _, err := br.ReadUint16()
 	if err != nil {
-		return nil, err
+		return err
+	}
+	if err = reserve[uint16](d, r, int(interfacesCount), 2); err != nil {
+		return err
 	}
-	interfaces := make([]uint16, interfacesCount)
+	d.Interfaces = make([]uint16, interfacesCount)
 	for i := uint16(0); i < interfacesCount; i++ {
-		interfaces[i], _, err = br.ReadUint16()
+		d.Interfaces[i], _, err = br.ReadUint16()
 		if err != nil {
-			return nil, err
+			return indexError(err, "interfaces", int(i))
 		}
 	}
 
-	c := &Class{
-		Minor:        minor,
-		Major:        major,
-		ConstantPool: cp,
-		AccessFlags:  accessFlags,
-		ThisClass:    thisClass,
-		SuperClass:   superClass,
-		Interfaces:   interfaces,
+	return nil
+}
+
+// WriteTo encodes the class file to w.
+//
+// Attributes are named by the constant pool entries they were read with, or,
+// for attributes that were not read, by the first CONSTANT_Utf8 entry holding
+// their name, so a Class that is read and then written is reproduced exactly.
+func (c *Class) WriteTo(w io.Writer) (int64, error) {
+	cw := countWriter{Writer: w}
+	e := encoder{
+		Class: c,
+		names: utf8Indexes(c.ConstantPool),
 	}
+	err := e.write(&cw)
+	return cw.n, err
+}
 
-	c.Fields, err = c.readFields(r)
-	if err != nil {
+// MarshalBinary returns the encoding of the class file, as written by WriteTo.
+func (c *Class) MarshalBinary() ([]byte, error) {
+	var buf bytes.Buffer
+	if _, err := c.WriteTo(&buf); err != nil {
 		return nil, err
 	}
+	return buf.Bytes(), nil
+}
 
-	c.Methods, err = c.readMethods(r)
-	if err != nil {
-		return nil, err
+// utf8Indexes maps each string in the constant pool to the index of the first
+// CONSTANT_Utf8 entry holding it.
+func utf8Indexes(pool []CPInfo) map[string]uint16 {
+	names := make(map[string]uint16)
+	for n, cpInfo := range pool {
+		if u, ok := cpInfo.(ConstantUTF8Info); ok {
+			if _, ok := names[u.String]; !ok {
+				names[u.String] = uint16(n)
+			}
+		}
 	}
+	return names
+}
 
-	c.Attributes, err = c.readAttributes(r)
-	if err != nil {
-		return nil, err
+type encoder struct {
+	*Class
+	names map[string]uint16
+}
+
+func (e *encoder) write(w io.Writer) error {
+	bw := byteio.BigEndianWriter{Writer: w}
+	if _, err := bw.WriteUint32(Magic); err != nil {
+		return err
+	}
+	if _, err := bw.WriteUint16(e.Minor); err != nil {
+		return err
+	}
+	if _, err := bw.WriteUint16(e.Major); err != nil {
+		return err
 	}
+	if err := writeConstantPool(w, e.ConstantPool); err != nil {
+		return err
+	}
+	if _, err := bw.WriteUint16(uint16(e.AccessFlags)); err != nil {
+		return err
+	}
+	if _, err := bw.WriteUint16(e.ThisClass); err != nil {
+		return err
+	}
+	if _, err := bw.WriteUint16(e.SuperClass); err != nil {
+		return err
+	}
+	if err := writeLength(w, len(e.Interfaces)); err != nil {
+		return err
+	}
+	for _, i := range e.Interfaces {
+		if _, err := bw.WriteUint16(i); err != nil {
+			return err
+		}
+	}
+	if err := e.writeFields(w, e.Fields); err != nil {
+		return err
+	}
+	if err := e.writeMethods(w, e.Methods); err != nil {
+		return err
+	}
+	return e.writeAttributes(w, e.Attributes)
+}
+
+func writeLength(w io.Writer, length int) error {
+	if length > math.MaxUint16 {
+		return ErrTooLarge
+	}
+	bw := byteio.BigEndianWriter{Writer: w}
+	_, err := bw.WriteUint16(uint16(length))
+	return err
+}
+
+type countWriter struct {
+	io.Writer
+	n int64
+}
 
-	return c, err
+func (c *countWriter) Write(p []byte) (int, error) {
+	n, err := c.Writer.Write(p)
+	c.n += int64(n)
+	return n, err
 }
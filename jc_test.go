@@ -0,0 +1,246 @@
+package javaclass
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// duplicateNamesClass returns a class whose attributes are named by the second
+// of two CONSTANT_Utf8 entries holding each name.
+func duplicateNamesClass() []byte {
+	code := u1(0xb1)
+	return cat(
+		u4(Magic),
+		u2(0, 52),
+		u2(12),
+		utf8Constant("A"),
+		cat(u1(ConstantClass), u2(1)),
+		utf8Constant("m"),
+		utf8Constant("()V"),
+		utf8Constant(AttrSourceFile),
+		utf8Constant(AttrSourceFile),
+		utf8Constant(AttrCode),
+		utf8Constant(AttrCode),
+		utf8Constant(AttrRuntimeVisibleAnnotations),
+		utf8Constant(AttrRuntimeVisibleAnnotations),
+		utf8Constant("LA;"),
+		u2(0x0021, 2, 0),
+		list2(),
+		list2(),
+		list2(cat(
+			u2(0x0001, 3, 4),
+			list2(
+				cat(u2(8), u4(13), u2(0, 1), u4(len(code)), code, list2(), list2()),
+				cat(u2(10), u4(6), list2(cat(u2(11), list2()))),
+			),
+		)),
+		list2(cat(u2(6), u4(2), u2(1))),
+	)
+}
+
+func TestWriteTo(t *testing.T) {
+	for n, data := range append(seedClassFiles(), duplicateNamesClass()) {
+		c, err := Parse(data)
+		if err != nil {
+			t.Fatalf("test %d: unexpected error parsing class: %s", n+1, err)
+		}
+		var buf bytes.Buffer
+		if m, err := c.WriteTo(&buf); err != nil {
+			t.Errorf("test %d: unexpected error writing class: %s", n+1, err)
+		} else if m != int64(len(data)) || !bytes.Equal(buf.Bytes(), data) {
+			t.Errorf("test %d: written class differs from input:\n%x\n%x", n+1, data, buf.Bytes())
+		}
+		var b ClassBuilder
+		c.Accept(&b)
+		if !reflect.DeepEqual(b.Class(), c) {
+			t.Errorf("test %d: class built by Accept differs from parsed class", n+1)
+		} else if built, err := b.Class().MarshalBinary(); err != nil {
+			t.Errorf("test %d: unexpected error writing built class: %s", n+1, err)
+		} else if !bytes.Equal(built, data) {
+			t.Errorf("test %d: written built class differs from input:\n%x\n%x", n+1, data, built)
+		}
+	}
+}
+
+func TestWriteToAttributeNames(t *testing.T) {
+	pool := []CPInfo{
+		nil,
+		ConstantUTF8Info{String: AttrSourceFile},
+		ConstantUTF8Info{String: "A"},
+		ConstantUTF8Info{String: AttrSourceFile},
+	}
+	for n, test := range [...]struct {
+		Attribute AttributeInfo
+		NameIndex byte
+		Err       error
+	}{
+		{SourceFileAttribute{SourceFileIndex: 2}, 1, nil},
+		{SourceFileAttribute{AttributeNameIndex: 3, SourceFileIndex: 2}, 3, nil},
+		{SourceFileAttribute{AttributeNameIndex: 2, SourceFileIndex: 2}, 1, nil},
+		{SourceFileAttribute{AttributeNameIndex: 4, SourceFileIndex: 2}, 1, nil},
+		{UnknownAttribute{AttributeNameIndex: 3, AttributeName: AttrSourceFile, Data: u2(2)}, 3, nil},
+		{SignatureAttribute{AttributeNameIndex: 1, SignatureIndex: 2}, 0, ErrMissingAttributeName},
+	} {
+		c := Class{
+			ConstantPool: pool,
+			Attributes:   []AttributeInfo{test.Attribute},
+		}
+		data, err := c.MarshalBinary()
+		if !errors.Is(err, test.Err) {
+			t.Errorf("test %d: expecting error %v, got %v", n+1, test.Err, err)
+		} else if err == nil {
+			if attribute := data[len(data)-8 : len(data)-6]; attribute[0] != 0 || attribute[1] != test.NameIndex {
+				t.Errorf("test %d: expecting name index %d, got %d", n+1, test.NameIndex, attribute[1])
+			}
+		}
+	}
+}
+
+func TestTrailingData(t *testing.T) {
+	class := cat(
+		u4(Magic),
+		u2(0, 52),
+		u2(3), utf8Constant("A"), cat(u1(ConstantClass), u2(1)),
+		u2(0, 2, 0),
+		u2(0), u2(0), u2(0), u2(0),
+	)
+	for n, trailing := range [...][]byte{{}, {0}, {0xca, 0xfe}} {
+		data := cat(class, trailing)
+		var expected error
+		if len(trailing) > 0 {
+			expected = ErrTrailingData
+		}
+		r := bytes.NewReader(data)
+		if _, err := Read(r); err != nil {
+			t.Errorf("test %d: Read: unexpected error: %s", n+1, err)
+		} else if r.Len() != len(trailing) {
+			t.Errorf("test %d: Read: expecting %d bytes to be left unread, got %d", n+1, len(trailing), r.Len())
+		}
+		var cb ClassBuilder
+		r.Reset(data)
+		if err := Accept(r, &cb); err != nil {
+			t.Errorf("test %d: Accept: unexpected error: %s", n+1, err)
+		} else if r.Len() != len(trailing) {
+			t.Errorf("test %d: Accept: expecting %d bytes to be left unread, got %d", n+1, len(trailing), r.Len())
+		}
+		_, parseErr := Parse(data)
+		_, openErr := Open(bytes.NewReader(data), int64(len(data)))
+		for _, err := range [...]struct {
+			Name string
+			Err  error
+		}{
+			{"Parse", parseErr},
+			{"Open", openErr},
+		} {
+			var pe *ParseError
+			if !errors.Is(err.Err, expected) {
+				t.Errorf("test %d: %s: expecting error %v, got %v", n+1, err.Name, expected, err.Err)
+			} else if expected != nil && (!errors.As(err.Err, &pe) || pe.Offset != int64(len(class))) {
+				t.Errorf("test %d: %s: expecting *ParseError at offset %d, got %v", n+1, err.Name, len(class), err.Err)
+			}
+		}
+	}
+}
+
+func TestConstantPoolCount(t *testing.T) {
+	for n, test := range [...]struct {
+		Count int
+		Pool  []byte
+		Err   error
+	}{
+		{Count: 0, Err: ErrInvalidConstantPoolCount},
+		{Count: 3, Pool: cat(u1(ConstantLong), u4(0, 1))},
+		{Count: 3, Pool: cat(u1(ConstantInteger), u4(1), u1(ConstantLong), u4(0, 1)), Err: ErrInvalidConstantPoolCount},
+		{Count: 4, Pool: cat(u1(ConstantInteger), u4(1), u1(ConstantLong), u4(0, 1))},
+		{Count: 2, Pool: cat(u1(ConstantDouble), u4(0, 1)), Err: ErrInvalidConstantPoolCount},
+	} {
+		data := cat(u4(Magic), u2(0, 52), u2(test.Count), test.Pool, u2(0, 0, 0), u2(0), u2(0), u2(0), u2(0))
+		c, err := Parse(data)
+		if !errors.Is(err, test.Err) {
+			t.Errorf("test %d: expecting error %v, got %v", n+1, test.Err, err)
+		} else if err == nil {
+			if out, err := c.MarshalBinary(); err != nil {
+				t.Errorf("test %d: unexpected error writing class: %s", n+1, err)
+			} else if !bytes.Equal(out, data) {
+				t.Errorf("test %d: expecting written class %x, got %x", n+1, data, out)
+			}
+		}
+	}
+}
+
+func TestAttributeTables(t *testing.T) {
+	names := [...]string{AttrSourceFile, AttrCode, AttrLineNumberTable, AttrSynthetic, AttrSignature, AttrConstantValue, AttrInnerClasses}
+	attr := func(name string, length int, body ...byte) []byte {
+		for n, attributeName := range names {
+			if attributeName == name {
+				return cat(u2(n+5), u4(length), body)
+			}
+		}
+		panic(name)
+	}
+	pool := cat(utf8Constant("A"), cat(u1(ConstantClass), u2(1)), utf8Constant("x"), utf8Constant("I"))
+	for _, name := range names {
+		pool = cat(pool, utf8Constant(name))
+	}
+	sourceFile := attr(AttrSourceFile, 2, 0, 3)
+	for n, test := range [...]struct {
+		Field, Class [][]byte
+		Err          error
+		Path         string
+		Unknown      []string
+	}{
+		{Class: [][]byte{sourceFile}},
+		{Class: [][]byte{attr(AttrSourceFile, 1, 0), u1(3)}, Err: ErrAttributeLength, Path: AttrSourceFile},
+		{Class: [][]byte{attr(AttrSourceFile, 3, 0, 3, 0)}, Err: ErrAttributeLength, Path: AttrSourceFile},
+		{Class: [][]byte{attr(AttrSynthetic, 1, 0)}, Err: ErrAttributeLength, Path: AttrSynthetic},
+		{Class: [][]byte{attr(AttrInnerClasses, 2, 0, 5)}, Err: ErrAttributeLength, Path: AttrInnerClasses},
+		{Field: [][]byte{attr(AttrSignature, 4, 0, 4, 0, 0)}, Err: ErrAttributeLength, Path: "fields[0]." + AttrSignature},
+		{Field: [][]byte{attr(AttrConstantValue, 1, 0), u1(4)}, Err: ErrAttributeLength, Path: "fields[0]." + AttrConstantValue},
+		{Class: [][]byte{sourceFile, sourceFile}, Err: ErrDuplicateAttribute, Path: AttrSourceFile},
+		{Field: [][]byte{attr(AttrSignature, 2, 0, 4), attr(AttrSignature, 2, 0, 4)}, Err: ErrDuplicateAttribute, Path: "fields[0]." + AttrSignature},
+		{Class: [][]byte{attr(AttrSynthetic, 0), attr(AttrSynthetic, 0)}},
+		{Field: [][]byte{attr(AttrCode, 3, 1, 2, 3)}, Unknown: []string{AttrCode}},
+		{Field: [][]byte{attr(AttrCode, 0), attr(AttrCode, 1, 1)}, Unknown: []string{AttrCode, AttrCode}},
+		{Class: [][]byte{attr(AttrLineNumberTable, 2, 0, 0)}, Unknown: []string{AttrLineNumberTable}},
+		{Class: [][]byte{attr(AttrConstantValue, 1, 9)}, Unknown: []string{AttrConstantValue}},
+	} {
+		data := cat(
+			u4(Magic),
+			u2(0, 52),
+			u2(5+len(names)), pool,
+			u2(0, 2, 0),
+			u2(0),
+			u2(1), u2(0, 3, 4), u2(len(test.Field)), cat(test.Field...),
+			u2(0),
+			u2(len(test.Class)), cat(test.Class...),
+		)
+		for _, read := range [...]func([]byte) (*Class, error){
+			Parse,
+			func(data []byte) (*Class, error) { return Read(bytes.NewReader(data)) },
+		} {
+			c, err := read(data)
+			var pe *ParseError
+			if !errors.Is(err, test.Err) {
+				t.Errorf("test %d: expecting error %v, got %v", n+1, test.Err, err)
+			} else if err != nil {
+				if !errors.As(err, &pe) || pe.Path != test.Path {
+					t.Errorf("test %d: expecting error path %q, got %v", n+1, test.Path, err)
+				}
+			} else {
+				var unknown []string
+				for _, attribute := range append(c.Fields[0].Attributes, c.Attributes...) {
+					if u, ok := attribute.(UnknownAttribute); ok {
+						unknown = append(unknown, u.AttributeName)
+					}
+				}
+				if !reflect.DeepEqual(unknown, test.Unknown) {
+					t.Errorf("test %d: expecting unknown attributes %v, got %v", n+1, test.Unknown, unknown)
+				} else if out, err := c.MarshalBinary(); err != nil || !bytes.Equal(out, data) {
+					t.Errorf("test %d: expecting written class to match input (%v)", n+1, err)
+				}
+			}
+		}
+	}
+}
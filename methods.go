@@ -2,45 +2,154 @@ package javaclass
 
 import (
 	"io"
+	"strconv"
 
 	"vimagination.zapto.org/byteio"
+	"vimagination.zapto.org/javaclass/descriptor"
 )
 
 type MethodInfo struct {
-	AccessFlags, NameIndex, DescriptorIndex uint16
-	Attributes                              []AttributeInfo
+	AccessFlags                MethodAccess
+	NameIndex, DescriptorIndex uint16
+	Attributes                 []AttributeInfo
 }
 
-func (c *Class) readMethods(r io.Reader) ([]MethodInfo, error) {
-	br := byteio.BigEndianReader{Reader: r}
+func (d *decoder) readMethods(r io.Reader) ([]MethodInfo, error) {
+	br := binaryReader{r}
 	methodsCount, _, err := br.ReadUint16()
 	if err != nil {
 		return nil, err
 	}
+	if err := reserve[MethodInfo](d, r, int(methodsCount), 8); err != nil {
+		return nil, err
+	}
 	methods := make([]MethodInfo, methodsCount)
 	for i := uint16(0); i < methodsCount; i++ {
-		af, _, err := br.ReadUint16()
-		if err != nil {
-			return nil, err
+		if methods[i], err = d.readMethod(r); err != nil {
+			return nil, indexError(err, "methods", int(i))
 		}
-		ni, _, err := br.ReadUint16()
-		if err != nil {
-			return nil, err
+	}
+	return methods, nil
+}
+
+func (d *decoder) readMethod(r io.Reader) (MethodInfo, error) {
+	br := binaryReader{r}
+	af, _, err := br.ReadUint16()
+	if err != nil {
+		return MethodInfo{}, err
+	}
+	ni, _, err := br.ReadUint16()
+	if err != nil {
+		return MethodInfo{}, err
+	}
+	di, _, err := br.ReadUint16()
+	if err != nil {
+		return MethodInfo{}, err
+	}
+	attributes, err := d.readAttributes(r, locationMethod)
+	if err != nil {
+		return MethodInfo{}, err
+	}
+	return MethodInfo{
+		AccessFlags:     MethodAccess(af),
+		NameIndex:       ni,
+		DescriptorIndex: di,
+		Attributes:      attributes,
+	}, nil
+}
+
+func (e *encoder) writeMethods(w io.Writer, methods []MethodInfo) error {
+	if err := writeLength(w, len(methods)); err != nil {
+		return err
+	}
+	bw := byteio.BigEndianWriter{Writer: w}
+	for _, m := range methods {
+		if _, err := bw.WriteUint16(uint16(m.AccessFlags)); err != nil {
+			return err
 		}
-		di, _, err := br.ReadUint16()
-		if err != nil {
-			return nil, err
+		if _, err := bw.WriteUint16(m.NameIndex); err != nil {
+			return err
 		}
-		attributes, err := c.readAttributes(r)
-		if err != nil {
-			return nil, err
+		if _, err := bw.WriteUint16(m.DescriptorIndex); err != nil {
+			return err
 		}
-		methods[i] = MethodInfo{
-			AccessFlags:     af,
-			NameIndex:       ni,
-			DescriptorIndex: di,
-			Attributes:      attributes,
+		if err := e.writeAttributes(w, m.Attributes); err != nil {
+			return err
 		}
 	}
-	return methods, nil
+	return nil
+}
+
+// Code returns the Code attribute of the method, which abstract and native
+// methods lack.
+func (m MethodInfo) Code() (CodeAttribute, bool) {
+	return FindAttribute[CodeAttribute](m.Attributes)
 }
+
+// ParameterNames returns a name for each parameter in the descriptor of the
+// given method.
+//
+// Names are taken from the MethodParameters attribute when present, otherwise
+// from the LocalVariableTable of the method's code, with any remaining
+// parameters given synthesized names of the form argN.
+func (c *Class) ParameterNames(method MethodInfo) ([]string, error) {
+	d, err := c.UTF8(method.DescriptorIndex)
+	if err != nil {
+		return nil, err
+	}
+	md, err := descriptor.ParseMethodDescriptor(d)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, len(md.Params))
+	var (
+		parameters     []MethodParameter
+		localVariables []LocalVariable
+	)
+	for _, attribute := range method.Attributes {
+		switch a := attribute.(type) {
+		case MethodParametersAttribute:
+			parameters = a.Parameters
+		case CodeAttribute:
+			for _, attribute := range a.Attributes {
+				if lvt, ok := attribute.(LocalVariableTableAttribute); ok {
+					localVariables = append(localVariables, lvt.LocalVariableTable...)
+				}
+			}
+		}
+	}
+	if len(parameters) == len(md.Params) {
+		for n, parameter := range parameters {
+			if parameter.NameIndex != 0 {
+				if names[n], err = c.UTF8(parameter.NameIndex); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+	var slot uint16
+	if !method.AccessFlags.IsStatic() {
+		slot = 1
+	}
+	for n, param := range md.Params {
+		if names[n] == "" {
+			for _, localVariable := range localVariables {
+				if localVariable.Index == slot && localVariable.StartPC == 0 {
+					if names[n], err = c.UTF8(localVariable.NameIndex); err != nil {
+						return nil, err
+					}
+					break
+				}
+			}
+		}
+		if names[n] == "" {
+			names[n] = "arg" + strconv.Itoa(n)
+		}
+		slot += uint16(param.Slots())
+	}
+	return names, nil
+}
+
+//Errors
+
+var ErrInvalidMethodDescriptor = descriptor.ErrInvalidMethodDescriptor
@@ -0,0 +1,151 @@
+package javaclass
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParameterNames(t *testing.T) {
+	type local struct {
+		StartPC, Index uint16
+		Name           string
+	}
+	for n, test := range [...]struct {
+		Static     bool
+		Descriptor string
+		Parameters []string
+		Locals     []local
+		Names      []string
+	}{
+		{
+			Static:     true,
+			Descriptor: "(ILjava/lang/String;)V",
+			Names:      []string{"arg0", "arg1"},
+		},
+		{
+			Static:     true,
+			Descriptor: "(ILjava/lang/String;)V",
+			Locals:     []local{{0, 0, "a"}, {0, 1, "b"}, {0, 2, "c"}},
+			Names:      []string{"a", "b"},
+		},
+		{
+			Static:     true,
+			Descriptor: "(ILjava/lang/String;)V",
+			Parameters: []string{"x", "y"},
+			Locals:     []local{{0, 0, "a"}, {0, 1, "b"}},
+			Names:      []string{"x", "y"},
+		},
+		{
+			Static:     true,
+			Descriptor: "(ILjava/lang/String;)V",
+			Parameters: []string{"", "y"},
+			Locals:     []local{{0, 0, "a"}, {0, 1, "b"}},
+			Names:      []string{"a", "y"},
+		},
+		{
+			Descriptor: "(ILjava/lang/String;)V",
+			Names:      []string{"arg0", "arg1"},
+		},
+		{
+			Descriptor: "(ILjava/lang/String;)V",
+			Locals:     []local{{0, 0, "this"}, {0, 1, "a"}, {0, 2, "b"}},
+			Names:      []string{"a", "b"},
+		},
+		{
+			Descriptor: "(ILjava/lang/String;)V",
+			Parameters: []string{"x", "y"},
+			Locals:     []local{{0, 0, "this"}, {0, 1, "a"}, {0, 2, "b"}},
+			Names:      []string{"x", "y"},
+		},
+		{
+			Descriptor: "(ILjava/lang/String;)V",
+			Parameters: []string{"x", ""},
+			Locals:     []local{{0, 0, "this"}, {0, 1, "a"}},
+			Names:      []string{"x", "arg1"},
+		},
+		{
+			Static:     true,
+			Descriptor: "(JDI)V",
+			Names:      []string{"arg0", "arg1", "arg2"},
+		},
+		{
+			Static:     true,
+			Descriptor: "(JDI)V",
+			Locals:     []local{{0, 0, "a"}, {0, 1, "wrong"}, {0, 2, "b"}, {0, 3, "wrong"}, {0, 4, "c"}},
+			Names:      []string{"a", "b", "c"},
+		},
+		{
+			Static:     true,
+			Descriptor: "(JDI)V",
+			Parameters: []string{"x", "y", "z"},
+			Locals:     []local{{0, 0, "a"}, {0, 2, "b"}, {0, 4, "c"}},
+			Names:      []string{"x", "y", "z"},
+		},
+		{
+			Static:     true,
+			Descriptor: "(JDI)V",
+			Parameters: []string{"x", "", ""},
+			Locals:     []local{{0, 0, "a"}, {0, 4, "c"}},
+			Names:      []string{"x", "arg1", "c"},
+		},
+		{
+			Descriptor: "(JDI)V",
+			Names:      []string{"arg0", "arg1", "arg2"},
+		},
+		{
+			Descriptor: "(JDI)V",
+			Locals:     []local{{0, 0, "this"}, {0, 1, "a"}, {0, 3, "b"}, {0, 5, "c"}},
+			Names:      []string{"a", "b", "c"},
+		},
+		{
+			Descriptor: "(JDI)V",
+			Parameters: []string{"x", "y", "z"},
+			Locals:     []local{{0, 0, "this"}, {0, 1, "a"}, {0, 3, "b"}, {0, 5, "c"}},
+			Names:      []string{"x", "y", "z"},
+		},
+		{
+			Descriptor: "(JDI)V",
+			Parameters: []string{"", "y", ""},
+			Locals:     []local{{0, 1, "a"}, {0, 3, "b"}},
+			Names:      []string{"a", "y", "arg2"},
+		},
+		{
+			Descriptor: "(JDI)V",
+			Locals:     []local{{4, 1, "late"}, {0, 1, "a"}, {2, 3, "late"}, {0, 5, "c"}},
+			Names:      []string{"a", "arg1", "c"},
+		},
+		{
+			Descriptor: "(JDI)V",
+			Parameters: []string{"x", "y"},
+			Locals:     []local{{0, 1, "a"}, {0, 3, "b"}, {0, 5, "c"}},
+			Names:      []string{"a", "b", "c"},
+		},
+	} {
+		c := &Class{ConstantPool: []CPInfo{nil}}
+		method := MethodInfo{DescriptorIndex: c.addUTF8(test.Descriptor)}
+		if test.Static {
+			method.AccessFlags = MethodStatic
+		}
+		if test.Parameters != nil {
+			mp := MethodParametersAttribute{Parameters: make([]MethodParameter, len(test.Parameters))}
+			for m, name := range test.Parameters {
+				if name != "" {
+					mp.Parameters[m].NameIndex = c.addUTF8(name)
+				}
+			}
+			method.Attributes = append(method.Attributes, mp)
+		}
+		if test.Locals != nil {
+			lvt := LocalVariableTableAttribute{LocalVariableTable: make([]LocalVariable, len(test.Locals))}
+			for m, l := range test.Locals {
+				lvt.LocalVariableTable[m] = LocalVariable{StartPC: l.StartPC, Length: 8, NameIndex: c.addUTF8(l.Name), Index: l.Index}
+			}
+			method.Attributes = append(method.Attributes, CodeAttribute{Code: make([]byte, 8), Attributes: []AttributeInfo{lvt}})
+		}
+		if names, err := c.ParameterNames(method); err != nil {
+			t.Errorf("test %d: unexpected error: %s", n+1, err)
+		} else if !reflect.DeepEqual(names, test.Names) {
+			t.Errorf("test %d: expecting names %q, got %q", n+1, test.Names, names)
+		}
+	}
+}
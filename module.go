@@ -0,0 +1,430 @@
+package javaclass
+
+import (
+	"errors"
+	"io"
+
+	"vimagination.zapto.org/byteio"
+)
+
+type ModuleRequires struct {
+	RequiresIndex        uint16
+	RequiresFlags        RequiresAccess
+	RequiresVersionIndex uint16
+}
+
+type ModuleExports struct {
+	ExportsIndex   uint16
+	ExportsFlags   ModuleAccess
+	ExportsToIndex []uint16
+}
+
+type ModuleOpens struct {
+	OpensIndex   uint16
+	OpensFlags   ModuleAccess
+	OpensToIndex []uint16
+}
+
+type ModuleProvides struct {
+	ProvidesIndex     uint16
+	ProvidesWithIndex []uint16
+}
+
+type ModuleAttribute struct {
+	AttributeNameIndex
+	ModuleNameIndex    uint16
+	ModuleFlags        ModuleAccess
+	ModuleVersionIndex uint16
+	Requires           []ModuleRequires
+	Exports            []ModuleExports
+	Opens              []ModuleOpens
+	UsesIndex          []uint16
+	Provides           []ModuleProvides
+}
+
+func (d *decoder) readModule(r io.Reader, nameIndex AttributeNameIndex) (AttributeInfo, error) {
+	br := binaryReader{r}
+	moduleNameIndex, _, err := br.ReadUint16()
+	if err != nil {
+		return nil, err
+	}
+	moduleFlags, _, err := br.ReadUint16()
+	if err != nil {
+		return nil, err
+	}
+	moduleVersionIndex, _, err := br.ReadUint16()
+	if err != nil {
+		return nil, err
+	}
+	requiresCount, _, err := br.ReadUint16()
+	if err != nil {
+		return nil, err
+	}
+	if err := reserve[ModuleRequires](d, r, int(requiresCount), 6); err != nil {
+		return nil, err
+	}
+	requires := make([]ModuleRequires, requiresCount)
+	for i := uint16(0); i < requiresCount; i++ {
+		requiresIndex, _, err := br.ReadUint16()
+		if err != nil {
+			return nil, err
+		}
+		requiresFlags, _, err := br.ReadUint16()
+		if err != nil {
+			return nil, err
+		}
+		requiresVersionIndex, _, err := br.ReadUint16()
+		if err != nil {
+			return nil, err
+		}
+		requires[i] = ModuleRequires{
+			RequiresIndex:        requiresIndex,
+			RequiresFlags:        RequiresAccess(requiresFlags),
+			RequiresVersionIndex: requiresVersionIndex,
+		}
+	}
+	exportsCount, _, err := br.ReadUint16()
+	if err != nil {
+		return nil, err
+	}
+	if err := reserve[ModuleExports](d, r, int(exportsCount), 6); err != nil {
+		return nil, err
+	}
+	exports := make([]ModuleExports, exportsCount)
+	for i := uint16(0); i < exportsCount; i++ {
+		exportsIndex, _, err := br.ReadUint16()
+		if err != nil {
+			return nil, err
+		}
+		exportsFlags, _, err := br.ReadUint16()
+		if err != nil {
+			return nil, err
+		}
+		exportsToIndex, err := d.readIndexTable(r)
+		if err != nil {
+			return nil, err
+		}
+		exports[i] = ModuleExports{
+			ExportsIndex:   exportsIndex,
+			ExportsFlags:   ModuleAccess(exportsFlags),
+			ExportsToIndex: exportsToIndex,
+		}
+	}
+	opensCount, _, err := br.ReadUint16()
+	if err != nil {
+		return nil, err
+	}
+	if err := reserve[ModuleOpens](d, r, int(opensCount), 6); err != nil {
+		return nil, err
+	}
+	opens := make([]ModuleOpens, opensCount)
+	for i := uint16(0); i < opensCount; i++ {
+		opensIndex, _, err := br.ReadUint16()
+		if err != nil {
+			return nil, err
+		}
+		opensFlags, _, err := br.ReadUint16()
+		if err != nil {
+			return nil, err
+		}
+		opensToIndex, err := d.readIndexTable(r)
+		if err != nil {
+			return nil, err
+		}
+		opens[i] = ModuleOpens{
+			OpensIndex:   opensIndex,
+			OpensFlags:   ModuleAccess(opensFlags),
+			OpensToIndex: opensToIndex,
+		}
+	}
+	usesIndex, err := d.readIndexTable(r)
+	if err != nil {
+		return nil, err
+	}
+	providesCount, _, err := br.ReadUint16()
+	if err != nil {
+		return nil, err
+	}
+	if err := reserve[ModuleProvides](d, r, int(providesCount), 4); err != nil {
+		return nil, err
+	}
+	provides := make([]ModuleProvides, providesCount)
+	for i := uint16(0); i < providesCount; i++ {
+		providesIndex, _, err := br.ReadUint16()
+		if err != nil {
+			return nil, err
+		}
+		providesWithIndex, err := d.readIndexTable(r)
+		if err != nil {
+			return nil, err
+		}
+		provides[i] = ModuleProvides{
+			ProvidesIndex:     providesIndex,
+			ProvidesWithIndex: providesWithIndex,
+		}
+	}
+	return ModuleAttribute{
+		AttributeNameIndex: nameIndex,
+		ModuleNameIndex:    moduleNameIndex,
+		ModuleFlags:        ModuleAccess(moduleFlags),
+		ModuleVersionIndex: moduleVersionIndex,
+		Requires:           requires,
+		Exports:            exports,
+		Opens:              opens,
+		UsesIndex:          usesIndex,
+		Provides:           provides,
+	}, nil
+}
+
+func (ModuleAttribute) Name() string {
+	return AttrModule
+}
+
+func writeModule(w io.Writer, m ModuleAttribute) error {
+	bw := byteio.BigEndianWriter{Writer: w}
+	if _, err := bw.WriteUint16(m.ModuleNameIndex); err != nil {
+		return err
+	}
+	if _, err := bw.WriteUint16(uint16(m.ModuleFlags)); err != nil {
+		return err
+	}
+	if _, err := bw.WriteUint16(m.ModuleVersionIndex); err != nil {
+		return err
+	}
+	if err := writeLength(w, len(m.Requires)); err != nil {
+		return err
+	}
+	for _, requires := range m.Requires {
+		if _, err := bw.WriteUint16(requires.RequiresIndex); err != nil {
+			return err
+		}
+		if _, err := bw.WriteUint16(uint16(requires.RequiresFlags)); err != nil {
+			return err
+		}
+		if _, err := bw.WriteUint16(requires.RequiresVersionIndex); err != nil {
+			return err
+		}
+	}
+	if err := writeLength(w, len(m.Exports)); err != nil {
+		return err
+	}
+	for _, exports := range m.Exports {
+		if _, err := bw.WriteUint16(exports.ExportsIndex); err != nil {
+			return err
+		}
+		if _, err := bw.WriteUint16(uint16(exports.ExportsFlags)); err != nil {
+			return err
+		}
+		if err := writeIndexTable(w, exports.ExportsToIndex); err != nil {
+			return err
+		}
+	}
+	if err := writeLength(w, len(m.Opens)); err != nil {
+		return err
+	}
+	for _, opens := range m.Opens {
+		if _, err := bw.WriteUint16(opens.OpensIndex); err != nil {
+			return err
+		}
+		if _, err := bw.WriteUint16(uint16(opens.OpensFlags)); err != nil {
+			return err
+		}
+		if err := writeIndexTable(w, opens.OpensToIndex); err != nil {
+			return err
+		}
+	}
+	if err := writeIndexTable(w, m.UsesIndex); err != nil {
+		return err
+	}
+	if err := writeLength(w, len(m.Provides)); err != nil {
+		return err
+	}
+	for _, provides := range m.Provides {
+		if _, err := bw.WriteUint16(provides.ProvidesIndex); err != nil {
+			return err
+		}
+		if err := writeIndexTable(w, provides.ProvidesWithIndex); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type ModulePackagesAttribute struct {
+	AttributeNameIndex
+	PackageIndex []uint16
+}
+
+func (d *decoder) readModulePackages(r io.Reader, nameIndex AttributeNameIndex) (AttributeInfo, error) {
+	packageIndex, err := d.readIndexTable(r)
+	if err != nil {
+		return nil, err
+	}
+	return ModulePackagesAttribute{nameIndex, packageIndex}, nil
+}
+
+func (ModulePackagesAttribute) Name() string {
+	return AttrModulePackages
+}
+
+type ModuleMainClassAttribute struct {
+	AttributeNameIndex
+	MainClassIndex uint16
+}
+
+func readModuleMainClass(r io.Reader, nameIndex AttributeNameIndex) (AttributeInfo, error) {
+	br := binaryReader{r}
+	n, _, err := br.ReadUint16()
+	if err != nil {
+		return nil, err
+	}
+	return ModuleMainClassAttribute{nameIndex, n}, nil
+}
+
+func (ModuleMainClassAttribute) Name() string {
+	return AttrModuleMainClass
+}
+
+func writeModuleMainClass(w io.Writer, m ModuleMainClassAttribute) error {
+	bw := byteio.BigEndianWriter{Writer: w}
+	_, err := bw.WriteUint16(m.MainClassIndex)
+	return err
+}
+
+type ModuleRequirement struct {
+	Module  string
+	Flags   RequiresAccess
+	Version string
+}
+
+type ModulePackageExport struct {
+	Package string
+	Flags   ModuleAccess
+	To      []string
+}
+
+type ModuleService struct {
+	Service string
+	With    []string
+}
+
+type ModuleDescriptor struct {
+	Name      string
+	Flags     ModuleAccess
+	Version   string
+	Requires  []ModuleRequirement
+	Exports   []ModulePackageExport
+	Opens     []ModulePackageExport
+	Uses      []string
+	Provides  []ModuleService
+	Packages  []string
+	MainClass string
+}
+
+func (c *Class) ModuleDescriptor() (*ModuleDescriptor, error) {
+	var (
+		module    *ModuleAttribute
+		packages  []uint16
+		mainClass uint16
+	)
+	for _, attribute := range c.Attributes {
+		switch a := attribute.(type) {
+		case ModuleAttribute:
+			module = &a
+		case ModulePackagesAttribute:
+			packages = a.PackageIndex
+		case ModuleMainClassAttribute:
+			mainClass = a.MainClassIndex
+		}
+	}
+	if module == nil {
+		return nil, ErrNotModule
+	}
+	name, err := c.moduleName(module.ModuleNameIndex)
+	if err != nil {
+		return nil, err
+	}
+	version, err := c.optionalUTF8(module.ModuleVersionIndex)
+	if err != nil {
+		return nil, err
+	}
+	md := &ModuleDescriptor{
+		Name:     name,
+		Flags:    module.ModuleFlags,
+		Version:  version,
+		Requires: make([]ModuleRequirement, len(module.Requires)),
+		Exports:  make([]ModulePackageExport, len(module.Exports)),
+		Opens:    make([]ModulePackageExport, len(module.Opens)),
+		Uses:     make([]string, len(module.UsesIndex)),
+		Provides: make([]ModuleService, len(module.Provides)),
+		Packages: make([]string, len(packages)),
+	}
+	for n, requires := range module.Requires {
+		if md.Requires[n].Module, err = c.moduleName(requires.RequiresIndex); err != nil {
+			return nil, err
+		}
+		if md.Requires[n].Version, err = c.optionalUTF8(requires.RequiresVersionIndex); err != nil {
+			return nil, err
+		}
+		md.Requires[n].Flags = requires.RequiresFlags
+	}
+	for n, exports := range module.Exports {
+		if md.Exports[n], err = c.modulePackageExport(exports.ExportsIndex, exports.ExportsFlags, exports.ExportsToIndex); err != nil {
+			return nil, err
+		}
+	}
+	for n, opens := range module.Opens {
+		if md.Opens[n], err = c.modulePackageExport(opens.OpensIndex, opens.OpensFlags, opens.OpensToIndex); err != nil {
+			return nil, err
+		}
+	}
+	for n, uses := range module.UsesIndex {
+		if md.Uses[n], err = c.ClassName(uses); err != nil {
+			return nil, err
+		}
+	}
+	for n, provides := range module.Provides {
+		if md.Provides[n].Service, err = c.ClassName(provides.ProvidesIndex); err != nil {
+			return nil, err
+		}
+		md.Provides[n].With = make([]string, len(provides.ProvidesWithIndex))
+		for m, with := range provides.ProvidesWithIndex {
+			if md.Provides[n].With[m], err = c.ClassName(with); err != nil {
+				return nil, err
+			}
+		}
+	}
+	for n, pkg := range packages {
+		if md.Packages[n], err = c.packageName(pkg); err != nil {
+			return nil, err
+		}
+	}
+	if mainClass != 0 {
+		if md.MainClass, err = c.ClassName(mainClass); err != nil {
+			return nil, err
+		}
+	}
+	return md, nil
+}
+
+func (c *Class) modulePackageExport(packageIndex uint16, flags ModuleAccess, toIndex []uint16) (ModulePackageExport, error) {
+	pkg, err := c.packageName(packageIndex)
+	if err != nil {
+		return ModulePackageExport{}, err
+	}
+	to := make([]string, len(toIndex))
+	for n, module := range toIndex {
+		if to[n], err = c.moduleName(module); err != nil {
+			return ModulePackageExport{}, err
+		}
+	}
+	return ModulePackageExport{
+		Package: pkg,
+		Flags:   flags,
+		To:      to,
+	}, nil
+}
+
+//Errors
+
+var ErrNotModule = errors.New("class is not a module descriptor")
@@ -0,0 +1,118 @@
+package javaclass
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func moduleInfo(moduleName, exportedPackage int) []byte {
+	module := cat(
+		u2(moduleName, 0x0020, 5),
+		list2(u2(7, 0x8000, 23), u2(13, 0x0060, 0)),
+		list2(cat(u2(exportedPackage, 0), list2()), cat(u2(11, 0x1000), list2(u2(13)))),
+		list2(cat(u2(11, 0), list2(u2(13)))),
+		list2(u2(15)),
+		list2(cat(u2(15), list2(u2(17)))),
+	)
+	packages := list2(u2(9), u2(11))
+	return cat(
+		u4(Magic),
+		u2(0, 53),
+		u2(24),
+		utf8Constant("module-info"),
+		cat(u1(ConstantClass), u2(1)),
+		utf8Constant("com.example"),
+		cat(u1(ConstantModule), u2(3)),
+		utf8Constant("1.0"),
+		utf8Constant("java.base"),
+		cat(u1(ConstantModule), u2(6)),
+		utf8Constant("com/example/api"),
+		cat(u1(ConstantPackage), u2(8)),
+		utf8Constant("com/example/impl"),
+		cat(u1(ConstantPackage), u2(10)),
+		utf8Constant("other.mod"),
+		cat(u1(ConstantModule), u2(12)),
+		utf8Constant("com/example/api/Service"),
+		cat(u1(ConstantClass), u2(14)),
+		utf8Constant("com/example/impl/ServiceImpl"),
+		cat(u1(ConstantClass), u2(16)),
+		utf8Constant("com/example/Main"),
+		cat(u1(ConstantClass), u2(18)),
+		utf8Constant(AttrModule),
+		utf8Constant(AttrModulePackages),
+		utf8Constant(AttrModuleMainClass),
+		utf8Constant("17"),
+		u2(0x8000, 2, 0),
+		list2(),
+		list2(),
+		list2(),
+		list2(
+			cat(u2(20), u4(len(module)), module),
+			cat(u2(21), u4(len(packages)), packages),
+			cat(u2(22), u4(2), u2(19)),
+		),
+	)
+}
+
+func TestModuleDescriptor(t *testing.T) {
+	c, err := Parse(moduleInfo(4, 9))
+	if err != nil {
+		t.Fatal(err)
+	}
+	md, err := c.ModuleDescriptor()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := &ModuleDescriptor{
+		Name:    "com.example",
+		Flags:   ModuleOpen,
+		Version: "1.0",
+		Requires: []ModuleRequirement{
+			{Module: "java.base", Flags: RequiresMandated, Version: "17"},
+			{Module: "other.mod", Flags: RequiresTransitive | RequiresStaticPhase},
+		},
+		Exports: []ModulePackageExport{
+			{Package: "com/example/api", To: []string{}},
+			{Package: "com/example/impl", Flags: ModuleSynthetic, To: []string{"other.mod"}},
+		},
+		Opens: []ModulePackageExport{
+			{Package: "com/example/impl", To: []string{"other.mod"}},
+		},
+		Uses: []string{"com/example/api/Service"},
+		Provides: []ModuleService{
+			{Service: "com/example/api/Service", With: []string{"com/example/impl/ServiceImpl"}},
+		},
+		Packages:  []string{"com/example/api", "com/example/impl"},
+		MainClass: "com/example/Main",
+	}
+	if !reflect.DeepEqual(md, expected) {
+		t.Errorf("expecting module descriptor %+v, got %+v", expected, md)
+	}
+}
+
+func TestModuleDescriptorErrors(t *testing.T) {
+	for n, test := range [...]struct {
+		ModuleName, ExportedPackage int
+		Index                       uint16
+		Tag                         int
+	}{
+		{2, 9, 2, ConstantClass},
+		{4, 7, 7, ConstantModule},
+		{4, 8, 8, ConstantUTF8},
+	} {
+		c, err := Parse(moduleInfo(test.ModuleName, test.ExportedPackage))
+		if err != nil {
+			t.Errorf("test %d: unexpected error: %s", n+1, err)
+			continue
+		}
+		_, err = c.ModuleDescriptor()
+		var ce *ConstantError
+		if !errors.As(err, &ce) || ce.Index != test.Index || ce.Tag != test.Tag || !errors.Is(err, ErrInvalidConstantPoolType) {
+			t.Errorf("test %d: expecting type error for constant %d with tag %d, got %v", n+1, test.Index, test.Tag, err)
+		}
+	}
+	if _, err := new(Class).ModuleDescriptor(); err != ErrNotModule {
+		t.Errorf("expecting error %v, got %v", ErrNotModule, err)
+	}
+}
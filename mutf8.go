@@ -0,0 +1,104 @@
+package javaclass
+
+import (
+	"errors"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// DecodeModifiedUTF8 decodes the modified UTF-8 encoding used by class files,
+// in which the null character is encoded in two bytes and supplementary
+// characters are encoded as surrogate pairs.
+//
+// Unpaired surrogates are decoded as utf8.RuneError.
+func DecodeModifiedUTF8(b []byte) (string, error) {
+	var (
+		runes []rune
+		ascii = true
+	)
+	for i := 0; i < len(b); {
+		c := b[i]
+		var r rune
+		switch {
+		case c == 0:
+			return "", ErrInvalidModifiedUTF8
+		case c < 0x80:
+			r = rune(c)
+			i++
+		case c&0xe0 == 0xc0:
+			if i+1 >= len(b) || b[i+1]&0xc0 != 0x80 {
+				return "", ErrInvalidModifiedUTF8
+			}
+			r = rune(c&0x1f)<<6 | rune(b[i+1]&0x3f)
+			if r != 0 && r < 0x80 {
+				return "", ErrInvalidModifiedUTF8
+			}
+			i += 2
+		case c&0xf0 == 0xe0:
+			if i+2 >= len(b) || b[i+1]&0xc0 != 0x80 || b[i+2]&0xc0 != 0x80 {
+				return "", ErrInvalidModifiedUTF8
+			}
+			r = rune(c&0x0f)<<12 | rune(b[i+1]&0x3f)<<6 | rune(b[i+2]&0x3f)
+			if r < 0x800 {
+				return "", ErrInvalidModifiedUTF8
+			}
+			i += 3
+		default:
+			return "", ErrInvalidModifiedUTF8
+		}
+		if r >= 0x80 || r == 0 {
+			ascii = false
+		}
+		runes = append(runes, r)
+	}
+	if ascii {
+		return string(b), nil
+	}
+	units := make([]uint16, len(runes))
+	for n, r := range runes {
+		units[n] = uint16(r)
+	}
+	return string(utf16.Decode(units)), nil
+}
+
+// EncodeModifiedUTF8 encodes a string using the modified UTF-8 encoding used
+// by class files.
+//
+// Invalid UTF-8 in s is encoded as utf8.RuneError.
+func EncodeModifiedUTF8(s string) []byte {
+	b := make([]byte, 0, len(s))
+	for _, r := range s {
+		if r >= 0x10000 {
+			r1, r2 := utf16.EncodeRune(r)
+			b = appendModifiedUTF8(appendModifiedUTF8(b, r1), r2)
+		} else {
+			b = appendModifiedUTF8(b, r)
+		}
+	}
+	return b
+}
+
+func appendModifiedUTF8(b []byte, r rune) []byte {
+	switch {
+	case r == 0:
+		return append(b, 0xc0, 0x80)
+	case r < 0x80:
+		return append(b, byte(r))
+	case r < 0x800:
+		return append(b, 0xc0|byte(r>>6), 0x80|byte(r)&0x3f)
+	}
+	return append(b, 0xe0|byte(r>>12), 0x80|byte(r>>6)&0x3f, 0x80|byte(r)&0x3f)
+}
+
+func isPlainASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] == 0 || s[i] >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
+
+//Errors
+
+var ErrInvalidModifiedUTF8 = errors.New("invalid modified UTF-8")
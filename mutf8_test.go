@@ -0,0 +1,105 @@
+package javaclass
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestDecodeModifiedUTF8(t *testing.T) {
+	for n, test := range [...]struct {
+		Input  []byte
+		Output string
+		Err    error
+	}{
+		{Input: []byte{}, Output: ""},
+		{Input: []byte("abc"), Output: "abc"},
+		{Input: []byte{0xc0, 0x80}, Output: "\x00"},
+		{Input: []byte{'a', 0xc0, 0x80, 'b'}, Output: "a\x00b"},
+		{Input: []byte{0xc3, 0xa9}, Output: "é"},
+		{Input: []byte{0xe2, 0x82, 0xac}, Output: "€"},
+		{Input: []byte{0xed, 0xa0, 0xbd, 0xed, 0xb8, 0x80}, Output: "\U0001f600"},
+		{Input: []byte{0xed, 0xa0, 0xbd}, Output: "�"},
+		{Input: []byte{0xed, 0xb8, 0x80}, Output: "�"},
+		{Input: []byte{0xed, 0xb8, 0x80, 0xed, 0xa0, 0xbd}, Output: "��"},
+		{Input: []byte{0xed, 0xa0, 0xbd, 'a'}, Output: "�a"},
+		{Input: []byte{0x00}, Err: ErrInvalidModifiedUTF8},
+		{Input: []byte{0xc1, 0x81}, Err: ErrInvalidModifiedUTF8},
+		{Input: []byte{0xc0, 0x81}, Err: ErrInvalidModifiedUTF8},
+		{Input: []byte{0xe0, 0x81, 0x81}, Err: ErrInvalidModifiedUTF8},
+		{Input: []byte{0xe0, 0x80, 0x80}, Err: ErrInvalidModifiedUTF8},
+		{Input: []byte{0xc3}, Err: ErrInvalidModifiedUTF8},
+		{Input: []byte{0xc3, 'a'}, Err: ErrInvalidModifiedUTF8},
+		{Input: []byte{0xe2, 0x82}, Err: ErrInvalidModifiedUTF8},
+		{Input: []byte{0xe2, 0x82, 'a'}, Err: ErrInvalidModifiedUTF8},
+		{Input: []byte{0x80}, Err: ErrInvalidModifiedUTF8},
+		{Input: []byte{0xf0, 0x9f, 0x98, 0x80}, Err: ErrInvalidModifiedUTF8},
+		{Input: []byte{0xff}, Err: ErrInvalidModifiedUTF8},
+	} {
+		if output, err := DecodeModifiedUTF8(test.Input); err != test.Err {
+			t.Errorf("test %d: expecting error %v, got %v", n+1, test.Err, err)
+		} else if output != test.Output {
+			t.Errorf("test %d: expecting string %q, got %q", n+1, test.Output, output)
+		}
+	}
+}
+
+func TestEncodeModifiedUTF8(t *testing.T) {
+	for n, test := range [...]struct {
+		Input  string
+		Output []byte
+	}{
+		{Input: "", Output: []byte{}},
+		{Input: "abc", Output: []byte("abc")},
+		{Input: "\x00", Output: []byte{0xc0, 0x80}},
+		{Input: "é€", Output: []byte{0xc3, 0xa9, 0xe2, 0x82, 0xac}},
+		{Input: "\U0001f600", Output: []byte{0xed, 0xa0, 0xbd, 0xed, 0xb8, 0x80}},
+		{Input: "\xff", Output: []byte{0xef, 0xbf, 0xbd}},
+	} {
+		if output := EncodeModifiedUTF8(test.Input); !bytes.Equal(output, test.Output) {
+			t.Errorf("test %d: expecting bytes %x, got %x", n+1, test.Output, output)
+		} else if str, err := DecodeModifiedUTF8(output); err != nil || str != test.Input && test.Input != "\xff" {
+			t.Errorf("test %d: expecting to decode %q, got %q (%v)", n+1, test.Input, str, err)
+		}
+	}
+}
+
+func TestConstantUTF8Bytes(t *testing.T) {
+	for n, test := range [...]struct {
+		Raw       []byte
+		String    string
+		Canonical bool
+	}{
+		{Raw: []byte("abc"), String: "abc", Canonical: true},
+		{Raw: []byte{0xc3, 0xa9, 0xc0, 0x80}, String: "é\x00", Canonical: true},
+		{Raw: []byte{0xef, 0xbf, 0xbd}, String: "�", Canonical: true},
+		{Raw: []byte{0xed, 0xa0, 0xbd}, String: "�"},
+		{Raw: []byte{'a', 0xed, 0xb8, 0x80, 0xed, 0xa0, 0xbd}, String: "a��"},
+	} {
+		data := cat(
+			u4(Magic),
+			u2(0, 65),
+			u2(4), cat(u1(ConstantUTF8), u2(len(test.Raw)), test.Raw), utf8Constant("C"), cat(u1(ConstantClass), u2(2)),
+			u2(0, 3, 0),
+			u2(0), u2(0), u2(0), u2(0),
+		)
+		c, err := Parse(data)
+		if err != nil {
+			t.Errorf("test %d: unexpected error: %s", n+1, err)
+			continue
+		}
+		u, ok := c.ConstantPool[1].(ConstantUTF8Info)
+		if !ok || u.String != test.String {
+			t.Errorf("test %d: expecting string %q, got %q", n+1, test.String, u.String)
+		} else if canonical := u == (ConstantUTF8Info{String: test.String}); canonical != test.Canonical {
+			t.Errorf("test %d: expecting comparison with a built constant to be %v", n+1, test.Canonical)
+		} else if b := u.Bytes(); !bytes.Equal(b, test.Raw) {
+			t.Errorf("test %d: expecting original bytes %x, got %x", n+1, test.Raw, b)
+		} else if out, err := c.MarshalBinary(); err != nil || !bytes.Equal(out, data) {
+			t.Errorf("test %d: expecting written class to match input (%v)", n+1, err)
+		}
+		u.String = "b"
+		if b := u.Bytes(); !bytes.Equal(b, []byte("b")) {
+			t.Errorf("test %d: expecting changed string to be encoded, got %x", n+1, b)
+		}
+	}
+}
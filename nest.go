@@ -0,0 +1,195 @@
+package javaclass
+
+import (
+	"errors"
+	"sort"
+)
+
+// NestInconsistency describes a class whose nest attributes disagree with
+// those of its nest host, or, when ClaimedHost is empty, a class that could not
+// be checked because Err was returned while resolving its constant pool.
+type NestInconsistency struct {
+	Class, ClaimedHost string
+	Err                error
+}
+
+// Nest is the nest host of a class and the members of its nest, which share
+// access to their private members.
+type Nest struct {
+	Host            string
+	Members         []string
+	Inconsistencies []NestInconsistency
+}
+
+// Nest determines the nest that c belongs to, using classes, keyed by internal
+// name, to look up the nest host and the other members of the nest.
+//
+// Inconsistencies are reported for member classes that claim a different host,
+// classes that claim the host but are not listed by it, and, when the host
+// lists its members, classes nested within the host, according to their
+// InnerClasses and EnclosingMethod attributes, that are not in the nest.
+//
+// Errors resolving the constant pool of c are returned, while those of the
+// other classes are reported as inconsistencies, with the classes otherwise
+// ignored. When the host itself cannot be resolved, the members of the nest
+// are those that claim the host.
+func (c *Class) Nest(classes map[string]*Class) (Nest, error) {
+	name, err := c.ClassName(c.ThisClass)
+	if err != nil {
+		return Nest{}, err
+	}
+	hostName, err := c.nestHost()
+	if err != nil {
+		return Nest{}, err
+	}
+	host := classes[hostName]
+	if hostName == name {
+		host = c
+	}
+	nest := Nest{Host: hostName}
+	listed := make(map[string]bool)
+	var hasMembers bool
+	if host != nil {
+		if nest.Members, hasMembers, err = host.nestMembers(); err != nil {
+			if host == c {
+				return Nest{}, err
+			}
+			nest.Inconsistencies = append(nest.Inconsistencies, NestInconsistency{
+				Class: hostName,
+				Err:   err,
+			})
+			host = nil
+		}
+		for _, member := range nest.Members {
+			listed[member] = true
+		}
+	}
+	loaded := make(map[string]*Class, len(classes)+1)
+	for className, class := range classes {
+		loaded[className] = class
+	}
+	loaded[name] = c
+	names := make([]string, 0, len(loaded))
+	for className := range loaded {
+		names = append(names, className)
+	}
+	sort.Strings(names)
+	for _, className := range names {
+		if className == hostName {
+			continue
+		}
+		class := loaded[className]
+		claimedHost, err := class.nestHost()
+		if err != nil {
+			nest.Inconsistencies = append(nest.Inconsistencies, NestInconsistency{
+				Class: className,
+				Err:   err,
+			})
+			continue
+		}
+		switch {
+		case listed[className]:
+			if claimedHost != hostName {
+				nest.Inconsistencies = append(nest.Inconsistencies, NestInconsistency{
+					Class:       className,
+					ClaimedHost: claimedHost,
+					Err:         ErrNestHostMismatch,
+				})
+			}
+		case claimedHost == hostName:
+			if host == nil {
+				nest.Members = append(nest.Members, className)
+			} else {
+				nest.Inconsistencies = append(nest.Inconsistencies, NestInconsistency{
+					Class:       className,
+					ClaimedHost: claimedHost,
+					Err:         ErrNestMemberNotListed,
+				})
+			}
+		case hasMembers:
+			outermost, err := class.outermostClass()
+			if err != nil {
+				nest.Inconsistencies = append(nest.Inconsistencies, NestInconsistency{
+					Class: className,
+					Err:   err,
+				})
+			} else if outermost == hostName {
+				nest.Inconsistencies = append(nest.Inconsistencies, NestInconsistency{
+					Class:       className,
+					ClaimedHost: claimedHost,
+					Err:         ErrNestMemberMissing,
+				})
+			}
+		}
+	}
+	return nest, nil
+}
+
+func (c *Class) nestHost() (string, error) {
+	for _, attribute := range c.Attributes {
+		if nh, ok := attribute.(NestHostAttribute); ok {
+			return c.ClassName(nh.HostClassIndex)
+		}
+	}
+	return c.ClassName(c.ThisClass)
+}
+
+func (c *Class) nestMembers() ([]string, bool, error) {
+	for _, attribute := range c.Attributes {
+		if nm, ok := attribute.(NestMembersAttribute); ok {
+			members := make([]string, len(nm.Classes))
+			for n, member := range nm.Classes {
+				var err error
+				if members[n], err = c.ClassName(member); err != nil {
+					return nil, false, err
+				}
+			}
+			return members, true, nil
+		}
+	}
+	return nil, false, nil
+}
+
+func (c *Class) outermostClass() (string, error) {
+	name, err := c.ClassName(c.ThisClass)
+	if err != nil {
+		return "", err
+	}
+	outer := make(map[string]uint16)
+	for _, attribute := range c.Attributes {
+		switch a := attribute.(type) {
+		case InnerClassesAttribute:
+			for _, class := range a.Classes {
+				inner, err := c.ClassName(class.InnerClassInfoIndex)
+				if err != nil {
+					return "", err
+				}
+				if class.OuterClassInfoIndex != 0 {
+					outer[inner] = class.OuterClassInfoIndex
+				}
+			}
+		case EnclosingMethodAttribute:
+			if _, ok := outer[name]; !ok {
+				outer[name] = a.ClassIndex
+			}
+		}
+	}
+	for seen := 0; seen <= len(outer); seen++ {
+		index, ok := outer[name]
+		if !ok {
+			break
+		}
+		if name, err = c.ClassName(index); err != nil {
+			return "", err
+		}
+	}
+	return name, nil
+}
+
+//Errors
+
+var (
+	ErrNestHostMismatch    = errors.New("nest member claims a different nest host")
+	ErrNestMemberNotListed = errors.New("class claims a nest host that does not list it")
+	ErrNestMemberMissing   = errors.New("nested class is not a member of its host's nest")
+)
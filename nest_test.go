@@ -0,0 +1,137 @@
+package javaclass
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// nestClass builds a class with the given name, and, when not empty, NestHost
+// and NestMembers attributes.
+func nestClass(name, host string, members ...string) *Class {
+	c := &Class{ConstantPool: []CPInfo{nil}}
+	c.ThisClass = c.addClass(name)
+	if host != "" {
+		c.Attributes = append(c.Attributes, NestHostAttribute{HostClassIndex: c.addClass(host)})
+	}
+	if members != nil {
+		nm := NestMembersAttribute{Classes: make([]uint16, len(members))}
+		for n, member := range members {
+			nm.Classes[n] = c.addClass(member)
+		}
+		c.Attributes = append(c.Attributes, nm)
+	}
+	return c
+}
+
+func TestNest(t *testing.T) {
+	host := nestClass("Outer", "", "Outer$A", "Outer$B")
+	a := nestClass("Outer$A", "Outer")
+	b := nestClass("Outer$B", "Outer")
+	other := nestClass("Other$B", "Other")
+	mismatch := nestClass("Outer$B", "Other")
+	unlisted := nestClass("Outer$C", "Outer")
+	nested := nestClass("Outer$D", "")
+	nested.Attributes = append(nested.Attributes, InnerClassesAttribute{Classes: []ClassInfo{
+		{InnerClassInfoIndex: nested.ThisClass, OuterClassInfoIndex: nested.addClass("Outer")},
+	}})
+	broken := nestClass("Broken", "")
+	broken.ThisClass = 1
+	brokenHost := nestClass("Outer", "", "Outer$A", "Outer$B")
+	brokenHost.Attributes[0] = NestMembersAttribute{Classes: []uint16{100}}
+	brokenErr := &ConstantError{Index: 1, Tag: ConstantUTF8, Err: ErrInvalidConstantPoolType}
+	brokenHostErr := &ConstantError{Index: 100, Err: ErrInvalidConstantPoolIndex}
+	for n, test := range [...]struct {
+		Class   *Class
+		Classes []*Class
+		Nest    Nest
+	}{
+		{
+			Class:   host,
+			Classes: []*Class{a, b, other},
+			Nest:    Nest{Host: "Outer", Members: []string{"Outer$A", "Outer$B"}},
+		},
+		{
+			Class:   a,
+			Classes: []*Class{host, b},
+			Nest:    Nest{Host: "Outer", Members: []string{"Outer$A", "Outer$B"}},
+		},
+		{
+			Class:   a,
+			Classes: []*Class{b},
+			Nest:    Nest{Host: "Outer", Members: []string{"Outer$A", "Outer$B"}},
+		},
+		{
+			Class:   other,
+			Classes: []*Class{host, a},
+			Nest:    Nest{Host: "Other", Members: []string{"Other$B"}},
+		},
+		{
+			Class:   host,
+			Classes: []*Class{a, mismatch},
+			Nest: Nest{
+				Host:            "Outer",
+				Members:         []string{"Outer$A", "Outer$B"},
+				Inconsistencies: []NestInconsistency{{Class: "Outer$B", ClaimedHost: "Other", Err: ErrNestHostMismatch}},
+			},
+		},
+		{
+			Class:   unlisted,
+			Classes: []*Class{host, a, b},
+			Nest: Nest{
+				Host:            "Outer",
+				Members:         []string{"Outer$A", "Outer$B"},
+				Inconsistencies: []NestInconsistency{{Class: "Outer$C", ClaimedHost: "Outer", Err: ErrNestMemberNotListed}},
+			},
+		},
+		{
+			Class:   host,
+			Classes: []*Class{a, b, nested},
+			Nest: Nest{
+				Host:            "Outer",
+				Members:         []string{"Outer$A", "Outer$B"},
+				Inconsistencies: []NestInconsistency{{Class: "Outer$D", ClaimedHost: "Outer$D", Err: ErrNestMemberMissing}},
+			},
+		},
+		{
+			Class:   a,
+			Classes: []*Class{host, b, broken},
+			Nest: Nest{
+				Host:            "Outer",
+				Members:         []string{"Outer$A", "Outer$B"},
+				Inconsistencies: []NestInconsistency{{Class: "Broken", Err: brokenErr}},
+			},
+		},
+		{
+			Class:   a,
+			Classes: []*Class{brokenHost, b},
+			Nest: Nest{
+				Host:            "Outer",
+				Members:         []string{"Outer$A", "Outer$B"},
+				Inconsistencies: []NestInconsistency{{Class: "Outer", Err: brokenHostErr}},
+			},
+		},
+	} {
+		classes := make(map[string]*Class)
+		for _, class := range test.Classes {
+			if class == broken {
+				classes["Broken"] = class
+			} else {
+				name, _ := class.Name()
+				classes[name] = class
+			}
+		}
+		nest, err := test.Class.Nest(classes)
+		if err != nil {
+			t.Errorf("test %d: unexpected error: %s", n+1, err)
+		} else if !reflect.DeepEqual(nest, test.Nest) {
+			t.Errorf("test %d: expecting nest %+v, got %+v", n+1, test.Nest, nest)
+		}
+	}
+	if _, err := broken.Nest(nil); !errors.Is(err, ErrInvalidConstantPoolType) {
+		t.Errorf("expecting error %v, got %v", ErrInvalidConstantPoolType, err)
+	}
+	if _, err := brokenHost.Nest(map[string]*Class{"Outer$A": a}); !errors.Is(err, ErrInvalidConstantPoolIndex) {
+		t.Errorf("expecting error %v, got %v", ErrInvalidConstantPoolIndex, err)
+	}
+}
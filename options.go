@@ -0,0 +1,154 @@
+package javaclass
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+	"unsafe"
+)
+
+const (
+	DefaultMaxCodeLength = 65535
+	DefaultMaxAllocation = 256 << 20
+	DefaultMaxDepth      = 64
+)
+
+// ReadOptions limits the resources used when decoding a class file. A zero
+// field selects the corresponding default.
+//
+// Every limit is checked before the memory it guards is allocated, so
+// arbitrary input, however hostile, results in either a Class or an error;
+// Read does not panic.
+type ReadOptions struct {
+	// MaxCodeLength is the largest code array accepted in a Code attribute.
+	MaxCodeLength uint32
+
+	// MaxAllocation is the total number of bytes that may be allocated
+	// for slices, strings and attribute data while decoding.
+	MaxAllocation int64
+
+	// MaxDepth is the deepest nesting of element values, through arrays
+	// and annotations, that will be decoded.
+	MaxDepth int
+
+	// AliasInput allows Parse to return code arrays, attribute data and
+	// strings that share memory with its input. As the strings may be kept
+	// long after the Class itself, the input must never be modified once
+	// parsed.
+	AliasInput bool
+
+	// SkipCode, SkipDebug and SkipFrames drop, respectively, Code
+	// attributes; LineNumberTable, LocalVariableTable,
+	// LocalVariableTypeTable and SourceDebugExtension attributes; and
+	// StackMapTable attributes. Their contents are skipped without being
+	// decoded, but must still be present in full.
+	SkipCode, SkipDebug, SkipFrames bool
+}
+
+func (o ReadOptions) skipAttribute(name string) bool {
+	switch name {
+	case AttrCode:
+		return o.SkipCode
+	case AttrLineNumberTable, AttrLocalVariableTable, AttrLocalVariableTypeTable, AttrSourceDebugExtension:
+		return o.SkipDebug
+	case AttrStackMapTable:
+		return o.SkipFrames
+	}
+	return false
+}
+
+func (o ReadOptions) maxCodeLength() uint32 {
+	if o.MaxCodeLength == 0 {
+		return DefaultMaxCodeLength
+	}
+	return o.MaxCodeLength
+}
+
+func (o ReadOptions) maxAllocation() int64 {
+	if o.MaxAllocation == 0 {
+		return DefaultMaxAllocation
+	}
+	return o.MaxAllocation
+}
+
+func (o ReadOptions) maxDepth() int {
+	if o.MaxDepth == 0 {
+		return DefaultMaxDepth
+	}
+	return o.MaxDepth
+}
+
+type decoder struct {
+	*Class
+	ReadOptions
+	allocated int64
+	depth     int
+	names     map[string]uint16
+}
+
+// reserve is called before allocating count elements of type T, each of which
+// is encoded in at least encodedSize bytes of r.
+//
+// When r is limited to the remainder of an attribute, counts that could not
+// possibly fit are rejected without allocating anything.
+func reserve[T any](d *decoder, r io.Reader, count, encodedSize int) error {
+	if count < 0 {
+		return ErrAllocationLimit
+	} else if lr, ok := r.(*io.LimitedReader); ok && int64(count)*int64(encodedSize) > lr.N {
+		return ErrAttributeLength
+	}
+	var t T
+	d.allocated += int64(count) * int64(unsafe.Sizeof(t))
+	if d.allocated > d.maxAllocation() {
+		return ErrAllocationLimit
+	}
+	return nil
+}
+
+func (d *decoder) readAll(r io.Reader) ([]byte, error) {
+	if lr, ok := r.(*io.LimitedReader); ok && d.AliasInput {
+		if data, ok := slice(lr, lr.N); ok {
+			return data, nil
+		}
+	}
+	data, err := ioutil.ReadAll(io.LimitReader(r, d.maxAllocation()-d.allocated+1))
+	if err != nil {
+		return nil, err
+	}
+	if err := reserve[byte](d, nil, len(data), 0); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// discard skips the remainder of an attribute.
+func discard(lr *io.LimitedReader) error {
+	if _, ok := slice(lr, lr.N); ok {
+		return nil
+	}
+	if _, err := io.Copy(io.Discard, lr); err != nil {
+		return err
+	} else if lr.N != 0 {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+
+func (d *decoder) enter() error {
+	if d.depth++; d.depth > d.maxDepth() {
+		return ErrDepthLimit
+	}
+	return nil
+}
+
+func (d *decoder) leave() {
+	d.depth--
+}
+
+//Errors
+
+var (
+	ErrCodeTooLong     = errors.New("code length exceeds limit")
+	ErrAllocationLimit = errors.New("allocation limit exceeded")
+	ErrDepthLimit      = errors.New("nesting depth limit exceeded")
+)
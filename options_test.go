@@ -0,0 +1,73 @@
+package javaclass
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestReadOptionsLimits(t *testing.T) {
+	seed := seedClassFiles()[0]
+	for n, test := range [...]struct {
+		ReadOptions
+		Err error
+	}{
+		{ReadOptions{MaxCodeLength: 1}, ErrCodeTooLong},
+		{ReadOptions{MaxAllocation: 1024}, ErrAllocationLimit},
+		{ReadOptions{MaxDepth: 1}, ErrDepthLimit},
+	} {
+		if _, err := test.Read(bytes.NewReader(seed)); !errors.Is(err, test.Err) {
+			t.Errorf("test %d: expecting error %s, got %v", n+1, test.Err, err)
+		}
+	}
+}
+
+func TestReadOptionsSkip(t *testing.T) {
+	seed := seedClassFiles()[0]
+	c, err := Parse(seed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	all := attributeNames(c)
+	for n, test := range [...]struct {
+		ReadOptions
+		Skipped []string
+	}{
+		{ReadOptions{SkipCode: true}, []string{AttrCode, AttrStackMapTable, AttrLineNumberTable, AttrLocalVariableTable, AttrLocalVariableTypeTable, AttrRuntimeVisibleTypeAnnotations, AttrRuntimeInvisibleTypeAnnotations}},
+		{ReadOptions{SkipDebug: true}, []string{AttrLineNumberTable, AttrLocalVariableTable, AttrLocalVariableTypeTable, AttrSourceDebugExtension}},
+		{ReadOptions{SkipFrames: true}, []string{AttrStackMapTable}},
+	} {
+		expected := make(map[string]int)
+		for name, count := range all {
+			expected[name] = count
+		}
+		for _, name := range test.Skipped {
+			expected[name]--
+			if expected[name] == 0 {
+				delete(expected, name)
+			}
+		}
+		for _, o := range []ReadOptions{test.ReadOptions, {AliasInput: true, SkipCode: test.SkipCode, SkipDebug: test.SkipDebug, SkipFrames: test.SkipFrames}} {
+			c, err := o.Parse(seed)
+			if err != nil {
+				t.Errorf("test %d: unexpected error: %s", n+1, err)
+			} else if found := attributeNames(c); !reflect.DeepEqual(found, expected) {
+				t.Errorf("test %d: expecting attributes %v, got %v", n+1, expected, found)
+			}
+			for i := range seed {
+				if _, err := o.Parse(seed[:i]); err == nil {
+					t.Errorf("test %d: expecting error parsing %d bytes", n+1, i)
+				}
+			}
+		}
+	}
+}
+
+func attributeNames(c *Class) map[string]int {
+	names := make(map[string]int)
+	for _, attribute := range allAttributes(c) {
+		names[attribute.Name()]++
+	}
+	return names
+}
@@ -0,0 +1,194 @@
+package javaclass
+
+import (
+	"encoding/binary"
+	"io"
+	"math"
+	"unsafe"
+)
+
+// Parse decodes a class file held in memory using the default ReadOptions.
+func Parse(data []byte) (*Class, error) {
+	return ReadOptions{}.Parse(data)
+}
+
+// Parse decodes a class file held in memory, which must fill all of data.
+//
+// Unless AliasInput is set, the returned Class does not refer to data.
+func (o ReadOptions) Parse(data []byte) (*Class, error) {
+	sr := sliceReader{data: data}
+	d := decoder{
+		Class:       new(Class),
+		ReadOptions: o,
+	}
+	err := d.read(&sr)
+	if err == nil && sr.pos != len(data) {
+		err = ErrTrailingData
+	}
+	if err != nil {
+		return nil, parseError(err, int64(sr.pos))
+	}
+	return d.Class, nil
+}
+
+type sliceReader struct {
+	data []byte
+	pos  int
+}
+
+func (s *sliceReader) Read(p []byte) (int, error) {
+	if s.pos >= len(s.data) {
+		return 0, io.EOF
+	}
+	n := copy(p, s.data[s.pos:])
+	s.pos += n
+	return n, nil
+}
+
+// slice takes the next n bytes from r without copying them, which is only
+// possible when r reads from a sliceReader, perhaps through the
+// io.LimitedReaders that bound each attribute.
+func slice(r io.Reader, n int64) ([]byte, bool) {
+	switch r := r.(type) {
+	case *sliceReader:
+		if n > int64(len(r.data)-r.pos) {
+			return nil, false
+		}
+		data := r.data[r.pos : r.pos+int(n) : r.pos+int(n)]
+		r.pos += int(n)
+		return data, true
+	case *io.LimitedReader:
+		if n > r.N {
+			return nil, false
+		}
+		data, ok := slice(r.R, n)
+		if ok {
+			r.N -= n
+		}
+		return data, ok
+	}
+	return nil, false
+}
+
+// next takes the next n bytes, no more than eight, from r, returning as many
+// as could be read alongside any error, as io.ReadFull would.
+//
+// The bytes are taken in place from a sliceReader, and read into a buffer held
+// by a countReader or scanReader, so reading a fixed-width value from any of
+// the readers used by the decoder does not allocate. The returned bytes are
+// only valid until the next read.
+func next(r io.Reader, n int) ([]byte, error) {
+	switch r := r.(type) {
+	case *sliceReader:
+		data := r.data[r.pos:]
+		if len(data) < n {
+			r.pos = len(r.data)
+			return data, readFullError(len(data))
+		}
+		r.pos += n
+		return data[:n], nil
+	case *io.LimitedReader:
+		m := n
+		if int64(m) > r.N {
+			m = int(r.N)
+		}
+		data, err := next(r.R, m)
+		r.N -= int64(len(data))
+		if err == nil && m < n {
+			err = readFullError(m)
+		}
+		return data, err
+	case *countReader:
+		m, err := io.ReadFull(r.Reader, r.buf[:n])
+		r.n += int64(m)
+		return r.buf[:m], err
+	case *scanReader:
+		m, err := io.ReadFull(r.buf, r.tmp[:n])
+		r.offset += int64(m)
+		return r.tmp[:m], err
+	}
+	data := make([]byte, n)
+	m, err := io.ReadFull(r, data)
+	return data[:m], err
+}
+
+func readFullError(n int) error {
+	if n == 0 {
+		return io.EOF
+	}
+	return io.ErrUnexpectedEOF
+}
+
+// binaryReader reads big-endian values using next, and so, unlike a
+// byteio.BigEndianReader, needs no buffer of its own.
+type binaryReader struct {
+	io.Reader
+}
+
+func (b binaryReader) ReadUint8() (uint8, int, error) {
+	data, err := next(b.Reader, 1)
+	if err != nil {
+		return 0, len(data), err
+	}
+	return data[0], 1, nil
+}
+
+func (b binaryReader) ReadUint16() (uint16, int, error) {
+	data, err := next(b.Reader, 2)
+	if err != nil {
+		return 0, len(data), err
+	}
+	return binary.BigEndian.Uint16(data), 2, nil
+}
+
+func (b binaryReader) ReadUint32() (uint32, int, error) {
+	data, err := next(b.Reader, 4)
+	if err != nil {
+		return 0, len(data), err
+	}
+	return binary.BigEndian.Uint32(data), 4, nil
+}
+
+func (b binaryReader) ReadUint64() (uint64, int, error) {
+	data, err := next(b.Reader, 8)
+	if err != nil {
+		return 0, len(data), err
+	}
+	return binary.BigEndian.Uint64(data), 8, nil
+}
+
+func (b binaryReader) ReadFloat32() (float32, int, error) {
+	v, n, err := b.ReadUint32()
+	return math.Float32frombits(v), n, err
+}
+
+func (b binaryReader) ReadFloat64() (float64, int, error) {
+	v, n, err := b.ReadUint64()
+	return math.Float64frombits(v), n, err
+}
+
+func (d *decoder) readBytes(r io.Reader, n int) ([]byte, error) {
+	if err := reserve[byte](d, r, n, 1); err != nil {
+		return nil, err
+	}
+	if d.AliasInput {
+		if data, ok := slice(r, int64(n)); ok {
+			return data, nil
+		}
+	}
+	data := make([]byte, n)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// byteString converts data to a string without copying it, and so must only
+// be used with data that is not modified afterwards: bytes that the decoder
+// has just allocated, or input that may be aliased.
+func byteString(data []byte) string {
+	if len(data) == 0 {
+		return ""
+	}
+	return unsafe.String(&data[0], len(data))
+}
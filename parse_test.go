@@ -0,0 +1,181 @@
+package javaclass
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	for n, seed := range seedClassFiles() {
+		expected, err := Read(bytes.NewReader(seed))
+		if err != nil {
+			t.Fatalf("test %d: unexpected error reading class: %s", n+1, err)
+		}
+		for _, o := range []ReadOptions{{}, {AliasInput: true}} {
+			data := append([]byte{}, seed...)
+			c, err := o.Parse(data)
+			if err != nil {
+				t.Errorf("test %d (%+v): unexpected error parsing class: %s", n+1, o, err)
+			} else if !reflect.DeepEqual(c, expected) {
+				t.Errorf("test %d (%+v): parsed class differs from read class", n+1, o)
+			}
+			if o.AliasInput {
+				continue
+			}
+			for i := range data {
+				data[i] = 0
+			}
+			if !reflect.DeepEqual(c, expected) {
+				t.Errorf("test %d: parsed class refers to its input", n+1)
+			}
+		}
+		for i := range seed {
+			_, readErr := Read(bytes.NewReader(seed[:i]))
+			_, parseErr := ReadOptions{AliasInput: true}.Parse(seed[:i])
+			if fmt.Sprint(readErr) != fmt.Sprint(parseErr) {
+				t.Errorf("test %d: reading %d bytes: expecting error %q, got %q", n+1, i, readErr, parseErr)
+			}
+		}
+	}
+}
+
+func TestBinaryReader(t *testing.T) {
+	data := []byte{0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08, 0x09}
+	var (
+		sr sliceReader
+		lr io.LimitedReader
+		br bytes.Reader
+		cr countReader
+	)
+	for n, test := range [...]struct {
+		Reset func(data []byte) io.Reader
+		Pos   func() int
+		End   int
+	}{
+		{
+			Reset: func(data []byte) io.Reader {
+				sr = sliceReader{data: data}
+				return &sr
+			},
+			Pos: func() int { return sr.pos },
+			End: 6,
+		},
+		{
+			Reset: func(data []byte) io.Reader {
+				sr = sliceReader{data: data}
+				lr = io.LimitedReader{R: &sr, N: int64(len(data)) - 1}
+				return &lr
+			},
+			Pos: func() int { return sr.pos },
+			End: 5,
+		},
+		{
+			Reset: func(data []byte) io.Reader {
+				br.Reset(data)
+				cr = countReader{Reader: &br}
+				return &cr
+			},
+			Pos: func() int { return int(cr.n) },
+			End: 6,
+		},
+	} {
+		var (
+			u8  uint8
+			u16 uint16
+			u32 uint32
+		)
+		if allocs := testing.AllocsPerRun(10, func() {
+			r := binaryReader{test.Reset(data)}
+			u8, _, _ = r.ReadUint8()
+			u16, _, _ = r.ReadUint16()
+			u32, _, _ = r.ReadUint32()
+		}); allocs != 0 {
+			t.Errorf("test %d: expecting no allocations, got %v", n+1, allocs)
+		}
+		if u8 != 0x01 || u16 != 0x0203 || u32 != 0x04050607 {
+			t.Errorf("test %d: read wrong values %x, %x, %x", n+1, u8, u16, u32)
+		}
+		r := binaryReader{test.Reset(data[:6])}
+		if _, _, err := r.ReadUint32(); err != nil {
+			t.Errorf("test %d: unexpected error: %s", n+1, err)
+		} else if _, _, err := r.ReadUint32(); err != io.ErrUnexpectedEOF {
+			t.Errorf("test %d: expecting error %v, got %v", n+1, io.ErrUnexpectedEOF, err)
+		} else if pos := test.Pos(); pos != test.End {
+			t.Errorf("test %d: expecting truncated read to end at %d, got %d", n+1, test.End, pos)
+		} else if _, _, err := r.ReadUint8(); err != io.EOF {
+			t.Errorf("test %d: expecting error %v, got %v", n+1, io.EOF, err)
+		}
+	}
+}
+
+// checkParseAllocations fails tb unless parsing data with AliasInput saves
+// at least one allocation, over reading it, for each non-empty CONSTANT_Utf8
+// entry.
+func checkParseAllocations(tb testing.TB, data []byte) {
+	c, err := Parse(data)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	var utf8 int
+	for _, cp := range c.ConstantPool {
+		if u, ok := cp.(ConstantUTF8Info); ok && u.String != "" {
+			utf8++
+		}
+	}
+	o := ReadOptions{AliasInput: true}
+	r := bytes.NewReader(data)
+	read := testing.AllocsPerRun(10, func() {
+		r.Reset(data)
+		Read(r)
+	})
+	parse := testing.AllocsPerRun(10, func() {
+		o.Parse(data)
+	})
+	if parse > read-float64(utf8) {
+		tb.Errorf("expecting Parse with AliasInput to allocate no more than %v times, got %v", read-float64(utf8), parse)
+	}
+}
+
+func TestParseAllocations(t *testing.T) {
+	for _, seed := range seedClassFiles() {
+		checkParseAllocations(t, seed)
+	}
+}
+
+func BenchmarkRead(b *testing.B) {
+	data := seedClassFiles()[0]
+	b.SetBytes(int64(len(data)))
+	b.ReportAllocs()
+	for n := 0; n < b.N; n++ {
+		if _, err := Read(bytes.NewReader(data)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkParse(b *testing.B) {
+	data := seedClassFiles()[0]
+	b.SetBytes(int64(len(data)))
+	b.ReportAllocs()
+	for n := 0; n < b.N; n++ {
+		if _, err := Parse(data); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkParseAliasInput(b *testing.B) {
+	data := seedClassFiles()[0]
+	o := ReadOptions{AliasInput: true}
+	checkParseAllocations(b, data)
+	b.SetBytes(int64(len(data)))
+	b.ReportAllocs()
+	for n := 0; n < b.N; n++ {
+		if _, err := o.Parse(data); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,205 @@
+package javaclass
+
+import "strconv"
+
+// ConstantError is returned by the constant pool accessors when an index is
+// out of range, or refers to a constant of the wrong type.
+type ConstantError struct {
+	Index uint16
+	Tag   int
+	Err   error
+}
+
+func (c *ConstantError) Error() string {
+	s := "constant_pool[" + strconv.Itoa(int(c.Index)) + "]: " + c.Err.Error()
+	if c.Err == ErrInvalidConstantPoolType {
+		s += " (tag " + strconv.Itoa(c.Tag) + ")"
+	}
+	return s
+}
+
+func (c *ConstantError) Unwrap() error {
+	return c.Err
+}
+
+func (c *Class) constant(i uint16) (CPInfo, error) {
+	if i == 0 || int(i) >= len(c.ConstantPool) || c.ConstantPool[i] == nil {
+		return nil, &ConstantError{Index: i, Err: ErrInvalidConstantPoolIndex}
+	}
+	return c.ConstantPool[i], nil
+}
+
+func typeError(i uint16, cpInfo CPInfo) error {
+	return &ConstantError{Index: i, Tag: cpInfo.Type(), Err: ErrInvalidConstantPoolType}
+}
+
+// UTF8 returns the string held in the ConstantUTF8Info at index i.
+func (c *Class) UTF8(i uint16) (string, error) {
+	cpInfo, err := c.constant(i)
+	if err != nil {
+		return "", err
+	}
+	u, ok := cpInfo.(ConstantUTF8Info)
+	if !ok {
+		return "", typeError(i, cpInfo)
+	}
+	return u.String, nil
+}
+
+// ClassName returns the internal name of the ConstantClassInfo at index i.
+func (c *Class) ClassName(i uint16) (string, error) {
+	cpInfo, err := c.constant(i)
+	if err != nil {
+		return "", err
+	}
+	class, ok := cpInfo.(ConstantClassInfo)
+	if !ok {
+		return "", typeError(i, cpInfo)
+	}
+	return c.UTF8(class.NameIndex)
+}
+
+// NameAndType returns the name and descriptor of the ConstantNameAndTypeInfo
+// at index i.
+func (c *Class) NameAndType(i uint16) (name, descriptor string, err error) {
+	cpInfo, err := c.constant(i)
+	if err != nil {
+		return "", "", err
+	}
+	nat, ok := cpInfo.(ConstantNameAndTypeInfo)
+	if !ok {
+		return "", "", typeError(i, cpInfo)
+	}
+	if name, err = c.UTF8(nat.NameIndex); err != nil {
+		return "", "", err
+	}
+	if descriptor, err = c.UTF8(nat.DescriptorIndex); err != nil {
+		return "", "", err
+	}
+	return name, descriptor, nil
+}
+
+// FieldRef returns the owning class, name and descriptor of the
+// ConstantFieldRefInfo at index i.
+func (c *Class) FieldRef(i uint16) (owner, name, descriptor string, err error) {
+	cpInfo, err := c.constant(i)
+	if err != nil {
+		return "", "", "", err
+	}
+	ref, ok := cpInfo.(ConstantFieldRefInfo)
+	if !ok {
+		return "", "", "", typeError(i, cpInfo)
+	}
+	return c.ref(ref.ClassIndex, ref.NameAndTypeIndex)
+}
+
+// MethodRef returns the owning class, name and descriptor of the
+// ConstantMethodRefInfo or ConstantInterfaceMethodRefInfo at index i.
+func (c *Class) MethodRef(i uint16) (owner, name, descriptor string, err error) {
+	cpInfo, err := c.constant(i)
+	if err != nil {
+		return "", "", "", err
+	}
+	switch ref := cpInfo.(type) {
+	case ConstantMethodRefInfo:
+		return c.ref(ref.ClassIndex, ref.NameAndTypeIndex)
+	case ConstantInterfaceMethodRefInfo:
+		return c.ref(ref.ClassIndex, ref.NameAndTypeIndex)
+	}
+	return "", "", "", typeError(i, cpInfo)
+}
+
+func (c *Class) memberRef(i uint16) (string, string, string, error) {
+	cpInfo, err := c.constant(i)
+	if err != nil {
+		return "", "", "", err
+	}
+	if _, ok := cpInfo.(ConstantFieldRefInfo); ok {
+		return c.FieldRef(i)
+	}
+	return c.MethodRef(i)
+}
+
+func (c *Class) ref(classIndex, nameAndTypeIndex uint16) (string, string, string, error) {
+	owner, err := c.ClassName(classIndex)
+	if err != nil {
+		return "", "", "", err
+	}
+	name, descriptor, err := c.NameAndType(nameAndTypeIndex)
+	if err != nil {
+		return "", "", "", err
+	}
+	return owner, name, descriptor, nil
+}
+
+// StringConst returns the string referred to by the ConstantStringInfo at
+// index i.
+func (c *Class) StringConst(i uint16) (string, error) {
+	cpInfo, err := c.constant(i)
+	if err != nil {
+		return "", err
+	}
+	s, ok := cpInfo.(ConstantStringInfo)
+	if !ok {
+		return "", typeError(i, cpInfo)
+	}
+	return c.UTF8(s.StringIndex)
+}
+
+type MethodHandle struct {
+	ReferenceKind           uint8
+	Owner, Name, Descriptor string
+}
+
+// MethodHandle resolves the ConstantMethodHandleInfo at index i.
+func (c *Class) MethodHandle(i uint16) (MethodHandle, error) {
+	cpInfo, err := c.constant(i)
+	if err != nil {
+		return MethodHandle{}, err
+	}
+	mh, ok := cpInfo.(ConstantMethodHandleInfo)
+	if !ok {
+		return MethodHandle{}, typeError(i, cpInfo)
+	}
+	owner, name, descriptor, err := c.memberRef(mh.ReferenceIndex)
+	if err != nil {
+		return MethodHandle{}, err
+	}
+	return MethodHandle{
+		ReferenceKind: mh.ReferenceKind,
+		Owner:         owner,
+		Name:          name,
+		Descriptor:    descriptor,
+	}, nil
+}
+
+func (c *Class) moduleName(i uint16) (string, error) {
+	cpInfo, err := c.constant(i)
+	if err != nil {
+		return "", err
+	}
+	module, ok := cpInfo.(ConstantModuleInfo)
+	if !ok {
+		return "", typeError(i, cpInfo)
+	}
+	return c.UTF8(module.NameIndex)
+}
+
+func (c *Class) packageName(i uint16) (string, error) {
+	cpInfo, err := c.constant(i)
+	if err != nil {
+		return "", err
+	}
+	pkg, ok := cpInfo.(ConstantPackageInfo)
+	if !ok {
+		return "", typeError(i, cpInfo)
+	}
+	return c.UTF8(pkg.NameIndex)
+}
+
+func (c *Class) optionalUTF8(i uint16) (string, error) {
+	if i == 0 {
+		return "", nil
+	}
+	return c.UTF8(i)
+}
@@ -0,0 +1,114 @@
+package javaclass
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestConstantPoolAccessors(t *testing.T) {
+	c := &Class{ConstantPool: []CPInfo{
+		nil,
+		ConstantUTF8Info{String: "A"},
+		ConstantClassInfo{NameIndex: 1},
+		ConstantUTF8Info{String: "x"},
+		ConstantUTF8Info{String: "I"},
+		ConstantNameAndTypeInfo{NameIndex: 3, DescriptorIndex: 4},
+		ConstantFieldRefInfo{ClassIndex: 2, NameAndTypeIndex: 5},
+		ConstantUTF8Info{String: "()V"},
+		ConstantNameAndTypeInfo{NameIndex: 3, DescriptorIndex: 7},
+		ConstantMethodRefInfo{ClassIndex: 2, NameAndTypeIndex: 8},
+		ConstantInterfaceMethodRefInfo{ClassIndex: 2, NameAndTypeIndex: 8},
+		ConstantStringInfo{StringIndex: 1},
+		ConstantMethodHandleInfo{ReferenceKind: RefGetField, ReferenceIndex: 6},
+		ConstantMethodHandleInfo{ReferenceKind: RefInvokeInterface, ReferenceIndex: 10},
+		ConstantLongInfo{Long: 1},
+		nil,
+		ConstantFieldRefInfo{ClassIndex: 1, NameAndTypeIndex: 5},
+		ConstantNameAndTypeInfo{NameIndex: 2, DescriptorIndex: 4},
+		ConstantMethodHandleInfo{ReferenceKind: RefInvokeStatic, ReferenceIndex: 11},
+		ConstantStringInfo{StringIndex: 30},
+		ConstantMethodRefInfo{ClassIndex: 2, NameAndTypeIndex: 17},
+	}}
+	utf8 := func(i uint16) (string, error) { return c.UTF8(i) }
+	className := func(i uint16) (string, error) { return c.ClassName(i) }
+	nameAndType := func(i uint16) (string, error) {
+		name, descriptor, err := c.NameAndType(i)
+		return name + " " + descriptor, err
+	}
+	fieldRef := func(i uint16) (string, error) {
+		owner, name, descriptor, err := c.FieldRef(i)
+		return owner + " " + name + " " + descriptor, err
+	}
+	methodRef := func(i uint16) (string, error) {
+		owner, name, descriptor, err := c.MethodRef(i)
+		return owner + " " + name + " " + descriptor, err
+	}
+	stringConst := func(i uint16) (string, error) { return c.StringConst(i) }
+	methodHandle := func(i uint16) (string, error) {
+		mh, err := c.MethodHandle(i)
+		return fmt.Sprint(mh), err
+	}
+	for n, test := range [...]struct {
+		Resolve func(uint16) (string, error)
+		Index   uint16
+		Output  string
+		Err     *ConstantError
+	}{
+		{Resolve: utf8, Index: 1, Output: "A"},
+		{Resolve: utf8, Index: 2, Err: &ConstantError{Index: 2, Tag: ConstantClass, Err: ErrInvalidConstantPoolType}},
+		{Resolve: utf8, Index: 0, Err: &ConstantError{Index: 0, Err: ErrInvalidConstantPoolIndex}},
+		{Resolve: utf8, Index: 15, Err: &ConstantError{Index: 15, Err: ErrInvalidConstantPoolIndex}},
+		{Resolve: utf8, Index: 21, Err: &ConstantError{Index: 21, Err: ErrInvalidConstantPoolIndex}},
+		{Resolve: className, Index: 2, Output: "A"},
+		{Resolve: className, Index: 1, Err: &ConstantError{Index: 1, Tag: ConstantUTF8, Err: ErrInvalidConstantPoolType}},
+		{Resolve: nameAndType, Index: 5, Output: "x I"},
+		{Resolve: nameAndType, Index: 6, Err: &ConstantError{Index: 6, Tag: ConstantFieldRef, Err: ErrInvalidConstantPoolType}},
+		{Resolve: nameAndType, Index: 17, Err: &ConstantError{Index: 2, Tag: ConstantClass, Err: ErrInvalidConstantPoolType}},
+		{Resolve: fieldRef, Index: 6, Output: "A x I"},
+		{Resolve: fieldRef, Index: 9, Err: &ConstantError{Index: 9, Tag: ConstantMethodRef, Err: ErrInvalidConstantPoolType}},
+		{Resolve: fieldRef, Index: 16, Err: &ConstantError{Index: 1, Tag: ConstantUTF8, Err: ErrInvalidConstantPoolType}},
+		{Resolve: methodRef, Index: 9, Output: "A x ()V"},
+		{Resolve: methodRef, Index: 10, Output: "A x ()V"},
+		{Resolve: methodRef, Index: 6, Err: &ConstantError{Index: 6, Tag: ConstantFieldRef, Err: ErrInvalidConstantPoolType}},
+		{Resolve: methodRef, Index: 20, Err: &ConstantError{Index: 2, Tag: ConstantClass, Err: ErrInvalidConstantPoolType}},
+		{Resolve: stringConst, Index: 11, Output: "A"},
+		{Resolve: stringConst, Index: 14, Err: &ConstantError{Index: 14, Tag: ConstantLong, Err: ErrInvalidConstantPoolType}},
+		{Resolve: stringConst, Index: 19, Err: &ConstantError{Index: 30, Err: ErrInvalidConstantPoolIndex}},
+		{Resolve: methodHandle, Index: 12, Output: fmt.Sprint(MethodHandle{RefGetField, "A", "x", "I"})},
+		{Resolve: methodHandle, Index: 13, Output: fmt.Sprint(MethodHandle{RefInvokeInterface, "A", "x", "()V"})},
+		{Resolve: methodHandle, Index: 11, Err: &ConstantError{Index: 11, Tag: ConstantString, Err: ErrInvalidConstantPoolType}},
+		{Resolve: methodHandle, Index: 18, Err: &ConstantError{Index: 11, Tag: ConstantString, Err: ErrInvalidConstantPoolType}},
+	} {
+		output, err := test.Resolve(test.Index)
+		if test.Err == nil {
+			if err != nil {
+				t.Errorf("test %d: unexpected error: %s", n+1, err)
+			} else if output != test.Output {
+				t.Errorf("test %d: expecting %q, got %q", n+1, test.Output, output)
+			}
+			continue
+		}
+		var ce *ConstantError
+		if !errors.As(err, &ce) {
+			t.Errorf("test %d: expecting *ConstantError, got %v", n+1, err)
+		} else if !reflect.DeepEqual(ce, test.Err) {
+			t.Errorf("test %d: expecting error %q, got %q", n+1, test.Err, ce)
+		}
+	}
+}
+
+func TestConstantErrorString(t *testing.T) {
+	for n, test := range [...]struct {
+		Err    *ConstantError
+		String string
+	}{
+		{&ConstantError{Index: 2, Tag: ConstantClass, Err: ErrInvalidConstantPoolType}, "constant_pool[2]: invalid constant pool type (tag 7)"},
+		{&ConstantError{Index: 21, Err: ErrInvalidConstantPoolIndex}, "constant_pool[21]: invalid constant pool index"},
+	} {
+		if s := test.Err.Error(); s != test.String {
+			t.Errorf("test %d: expecting %q, got %q", n+1, test.String, s)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package javaclass
+
+import "errors"
+
+// RecordComponent is a resolved component of a record class.
+type RecordComponent struct {
+	Name, Descriptor, Signature string
+	Attributes                  []AttributeInfo
+}
+
+// RecordComponents returns the components of a record class, in declaration
+// order, with their names, descriptors and, for components of a generic type,
+// signatures resolved. The attributes of each component, such as its
+// annotations, are returned unchanged.
+//
+// ErrNotRecord is returned when c has no Record attribute.
+func (c *Class) RecordComponents() ([]RecordComponent, error) {
+	for _, attribute := range c.Attributes {
+		record, ok := attribute.(RecordAttribute)
+		if !ok {
+			continue
+		}
+		components := make([]RecordComponent, len(record.Components))
+		for n, component := range record.Components {
+			name, err := c.UTF8(component.NameIndex)
+			if err != nil {
+				return nil, err
+			}
+			descriptor, err := c.UTF8(component.DescriptorIndex)
+			if err != nil {
+				return nil, err
+			}
+			var signature string
+			for _, attribute := range component.Attributes {
+				if s, ok := attribute.(SignatureAttribute); ok {
+					if signature, err = c.UTF8(s.SignatureIndex); err != nil {
+						return nil, err
+					}
+				}
+			}
+			components[n] = RecordComponent{
+				Name:       name,
+				Descriptor: descriptor,
+				Signature:  signature,
+				Attributes: component.Attributes,
+			}
+		}
+		return components, nil
+	}
+	return nil, ErrNotRecord
+}
+
+// PermittedSubclasses returns the internal names of the classes permitted to
+// extend or implement a sealed class, or nil if c is not sealed.
+func (c *Class) PermittedSubclasses() ([]string, error) {
+	for _, attribute := range c.Attributes {
+		if ps, ok := attribute.(PermittedSubclassesAttribute); ok {
+			classes := make([]string, len(ps.Classes))
+			for n, class := range ps.Classes {
+				var err error
+				if classes[n], err = c.ClassName(class); err != nil {
+					return nil, err
+				}
+			}
+			return classes, nil
+		}
+	}
+	return nil, nil
+}
+
+//Errors
+
+var ErrNotRecord = errors.New("class is not a record")
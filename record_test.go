@@ -0,0 +1,54 @@
+package javaclass
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestRecordComponents(t *testing.T) {
+	c := &Class{ConstantPool: []CPInfo{nil}}
+	c.ThisClass = c.addClass("Point")
+	annotation := RuntimeVisibleAnnotationsAttribute{Annotations: []Annotation{{TypeIndex: c.addUTF8("LNonNull;")}}}
+	signature := SignatureAttribute{SignatureIndex: c.addUTF8("Ljava/util/List<Ljava/lang/String;>;")}
+	record := RecordAttribute{Components: []RecordComponentInfo{
+		{NameIndex: c.addUTF8("x"), DescriptorIndex: c.addUTF8("I")},
+		{NameIndex: c.addUTF8("names"), DescriptorIndex: c.addUTF8("Ljava/util/List;"), Attributes: []AttributeInfo{annotation, signature}},
+	}}
+	if _, err := c.RecordComponents(); err != ErrNotRecord {
+		t.Errorf("expecting error %v, got %v", ErrNotRecord, err)
+	}
+	c.Attributes = []AttributeInfo{record}
+	components, err := c.RecordComponents()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	expected := []RecordComponent{
+		{Name: "x", Descriptor: "I"},
+		{
+			Name:       "names",
+			Descriptor: "Ljava/util/List;",
+			Signature:  "Ljava/util/List<Ljava/lang/String;>;",
+			Attributes: []AttributeInfo{annotation, signature},
+		},
+	}
+	if !reflect.DeepEqual(components, expected) {
+		t.Errorf("expecting components %+v, got %+v", expected, components)
+	}
+	if typeName, err := c.UTF8(components[1].Attributes[0].(RuntimeVisibleAnnotationsAttribute).Annotations[0].TypeIndex); err != nil || typeName != "LNonNull;" {
+		t.Errorf("expecting annotation type \"LNonNull;\", got %q (%v)", typeName, err)
+	}
+	for n, set := range [...]func(*RecordComponentInfo){
+		func(rc *RecordComponentInfo) { rc.NameIndex = 100 },
+		func(rc *RecordComponentInfo) { rc.DescriptorIndex = c.ThisClass },
+		func(rc *RecordComponentInfo) { rc.Attributes = []AttributeInfo{SignatureAttribute{SignatureIndex: 0}} },
+	} {
+		broken := RecordAttribute{Components: append([]RecordComponentInfo{}, record.Components...)}
+		set(&broken.Components[1])
+		c.Attributes = []AttributeInfo{broken}
+		var ce *ConstantError
+		if _, err := c.RecordComponents(); !errors.As(err, &ce) {
+			t.Errorf("test %d: expecting *ConstantError, got %v", n+1, err)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package javaclass
+
+import (
+	"io"
+	"sync"
+)
+
+type AttributeDecoder func(c *Class, r io.Reader) (AttributeInfo, error)
+
+type AttributeEncoder func(c *Class, w io.Writer, a AttributeInfo) error
+
+type attributeCodec struct {
+	decoder AttributeDecoder
+	encoder AttributeEncoder
+}
+
+var attributeRegistry = struct {
+	sync.RWMutex
+	codecs map[string]attributeCodec
+}{
+	codecs: make(map[string]attributeCodec),
+}
+
+// RegisterAttribute sets the functions used to read and write attributes with
+// the given name that are not otherwise understood by this package, replacing
+// any functions previously registered for the name. Registering two nil
+// functions removes the registration.
+//
+// The predefined attributes, named by the Attr constants, are always handled
+// by this package, and the registry is never consulted for their names. When
+// no decoder is registered for an attribute name, the attribute is read as an
+// UnknownAttribute.
+//
+// The registry is global, shared by every Class in the program, and
+// RegisterAttribute is safe to call concurrently with itself and with the
+// reading and writing of classes.
+func RegisterAttribute(name string, decoder AttributeDecoder, encoder AttributeEncoder) {
+	attributeRegistry.Lock()
+	if decoder == nil && encoder == nil {
+		delete(attributeRegistry.codecs, name)
+	} else {
+		attributeRegistry.codecs[name] = attributeCodec{
+			decoder: decoder,
+			encoder: encoder,
+		}
+	}
+	attributeRegistry.Unlock()
+}
+
+func registeredAttribute(name string) attributeCodec {
+	attributeRegistry.RLock()
+	codec := attributeRegistry.codecs[name]
+	attributeRegistry.RUnlock()
+	return codec
+}
+
+func (d *decoder) readCustomAttribute(name string, nameIndex AttributeNameIndex, r io.Reader) (AttributeInfo, error) {
+	if decoder := registeredAttribute(name).decoder; decoder != nil {
+		return decoder(d.Class, r)
+	}
+	return d.readUnknownAttribute(name, nameIndex, r)
+}
+
+func (d *decoder) readUnknownAttribute(name string, nameIndex AttributeNameIndex, r io.Reader) (AttributeInfo, error) {
+	data, err := d.readAll(r)
+	if err != nil {
+		return nil, err
+	}
+	return UnknownAttribute{
+		AttributeNameIndex: nameIndex,
+		AttributeName:      name,
+		Data:               data,
+	}, nil
+}
+
+func (e *encoder) writeCustomAttribute(w io.Writer, a AttributeInfo) error {
+	if encoder := registeredAttribute(a.Name()).encoder; encoder != nil {
+		return encoder(e.Class, w, a)
+	}
+	return ErrUnknownAttributeType
+}
+
+type UnknownAttribute struct {
+	AttributeNameIndex
+	AttributeName string
+	Data          []byte
+}
+
+func (u UnknownAttribute) Name() string {
+	return u.AttributeName
+}
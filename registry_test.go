@@ -0,0 +1,98 @@
+package javaclass
+
+import (
+	"bytes"
+	"io"
+	"reflect"
+	"testing"
+)
+
+type testAttribute struct {
+	AttributeNameIndex
+	Value uint8
+	Codec int
+}
+
+func (testAttribute) Name() string {
+	return "Custom"
+}
+
+func testAttributeCodec(codec int) (AttributeDecoder, AttributeEncoder) {
+	return func(c *Class, r io.Reader) (AttributeInfo, error) {
+			var b [1]byte
+			if _, err := io.ReadFull(r, b[:]); err != nil {
+				return nil, err
+			}
+			return testAttribute{Value: b[0], Codec: codec}, nil
+		}, func(c *Class, w io.Writer, a AttributeInfo) error {
+			_, err := w.Write([]byte{a.(testAttribute).Value})
+			return err
+		}
+}
+
+func registryClass() []byte {
+	return cat(
+		u4(Magic),
+		u2(0, 52),
+		u2(5),
+		utf8Constant("A"),
+		cat(u1(ConstantClass), u2(1)),
+		utf8Constant("Custom"),
+		utf8Constant(AttrSourceFile),
+		u2(0x0021, 2, 0),
+		list2(),
+		list2(),
+		list2(),
+		list2(
+			cat(u2(3), u4(1), u1(7)),
+			cat(u2(4), u4(2), u2(1)),
+		),
+	)
+}
+
+func TestRegisterAttribute(t *testing.T) {
+	defer RegisterAttribute("Custom", nil, nil)
+	defer RegisterAttribute(AttrSourceFile, nil, nil)
+	data := registryClass()
+	sourceFile := SourceFileAttribute{AttributeNameIndex: 4, SourceFileIndex: 1}
+	first, _ := testAttributeCodec(1)
+	RegisterAttribute(AttrSourceFile, first, nil)
+	for n, test := range [...]struct {
+		Codec    int
+		Expected AttributeInfo
+	}{
+		{0, UnknownAttribute{AttributeNameIndex: 3, AttributeName: "Custom", Data: []byte{7}}},
+		{1, testAttribute{Value: 7, Codec: 1}},
+		{2, testAttribute{Value: 7, Codec: 2}},
+		{0, UnknownAttribute{AttributeNameIndex: 3, AttributeName: "Custom", Data: []byte{7}}},
+	} {
+		if test.Codec == 0 {
+			RegisterAttribute("Custom", nil, nil)
+		} else {
+			decoder, encoder := testAttributeCodec(test.Codec)
+			RegisterAttribute("Custom", decoder, encoder)
+		}
+		c, err := Parse(data)
+		if err != nil {
+			t.Errorf("test %d: unexpected error: %s", n+1, err)
+			continue
+		}
+		if expected := []AttributeInfo{test.Expected, sourceFile}; !reflect.DeepEqual(c.Attributes, expected) {
+			t.Errorf("test %d: expecting attributes %v, got %v", n+1, expected, c.Attributes)
+		}
+		written, err := c.MarshalBinary()
+		if err != nil {
+			t.Errorf("test %d: unexpected error writing class: %s", n+1, err)
+		} else if !bytes.Equal(written, data) {
+			t.Errorf("test %d: written class differs from input:\n%x\n%x", n+1, data, written)
+		}
+	}
+	RegisterAttribute("Custom", nil, nil)
+	c := Class{
+		ConstantPool: []CPInfo{nil, ConstantUTF8Info{String: "Custom"}},
+		Attributes:   []AttributeInfo{testAttribute{Value: 1}},
+	}
+	if _, err := c.MarshalBinary(); err != ErrUnknownAttributeType {
+		t.Errorf("expecting error %v writing unregistered attribute, got %v", ErrUnknownAttributeType, err)
+	}
+}
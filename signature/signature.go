@@ -0,0 +1,446 @@
+// Package signature parses and formats the generic signatures of the Java
+// class file format, as found in Signature attributes.
+package signature // import "vimagination.zapto.org/javaclass/signature"
+
+import (
+	"errors"
+	"strings"
+
+	"vimagination.zapto.org/javaclass/descriptor"
+)
+
+// Type is a Java type signature: a descriptor.BaseType, a ClassType, a
+// TypeVariable or an ArrayType.
+//
+// The String method returns the type in signature form.
+type Type interface {
+	String() string
+}
+
+// ClassType is a, possibly parameterized, class or interface type.
+//
+// Classes lists the outermost class, followed by each class nested in the one
+// before it, as in java/util/Map<K, V>.Entry<K, V>. A nested class without
+// type arguments of its own may instead appear in the name of the outer class,
+// as in java/util/Map$Entry.
+type ClassType struct {
+	Package string
+	Classes []SimpleClassType
+}
+
+type SimpleClassType struct {
+	Name          string
+	TypeArguments []TypeArgument
+}
+
+// InternalName returns the internal name of the erasure of the type, such as
+// java/util/Map$Entry.
+func (c ClassType) InternalName() string {
+	var sb strings.Builder
+	if c.Package != "" {
+		sb.WriteString(c.Package)
+		sb.WriteByte('/')
+	}
+	for n, s := range c.Classes {
+		if n > 0 {
+			sb.WriteByte('$')
+		}
+		sb.WriteString(s.Name)
+	}
+	return sb.String()
+}
+
+func (c ClassType) String() string {
+	var sb strings.Builder
+	sb.WriteByte('L')
+	if c.Package != "" {
+		sb.WriteString(c.Package)
+		sb.WriteByte('/')
+	}
+	for n, s := range c.Classes {
+		if n > 0 {
+			sb.WriteByte('.')
+		}
+		sb.WriteString(s.Name)
+		if len(s.TypeArguments) > 0 {
+			sb.WriteByte('<')
+			for _, a := range s.TypeArguments {
+				sb.WriteString(a.String())
+			}
+			sb.WriteByte('>')
+		}
+	}
+	sb.WriteByte(';')
+	return sb.String()
+}
+
+type Wildcard byte
+
+const (
+	NoWildcard Wildcard = 0
+	Unbounded  Wildcard = '*'
+	Extends    Wildcard = '+'
+	Super      Wildcard = '-'
+)
+
+// TypeArgument is an argument to a parameterized type. Type is nil for the
+// Unbounded wildcard, ?.
+type TypeArgument struct {
+	Wildcard Wildcard
+	Type     Type
+}
+
+func (t TypeArgument) String() string {
+	switch t.Wildcard {
+	case Unbounded:
+		return "*"
+	case Extends, Super:
+		return string(t.Wildcard) + t.Type.String()
+	}
+	return t.Type.String()
+}
+
+type TypeVariable struct {
+	Name string
+}
+
+func (t TypeVariable) String() string {
+	return "T" + t.Name + ";"
+}
+
+// ArrayType is an array of Elem, which may itself be an ArrayType.
+type ArrayType struct {
+	Elem Type
+}
+
+func (a ArrayType) String() string {
+	return "[" + a.Elem.String()
+}
+
+// TypeParameter is a formal type parameter of a generic class or method.
+// ClassBound is nil when the parameter only has interface bounds.
+type TypeParameter struct {
+	Name            string
+	ClassBound      Type
+	InterfaceBounds []Type
+}
+
+func (t TypeParameter) String() string {
+	var sb strings.Builder
+	sb.WriteString(t.Name)
+	sb.WriteByte(':')
+	if t.ClassBound != nil {
+		sb.WriteString(t.ClassBound.String())
+	}
+	for _, b := range t.InterfaceBounds {
+		sb.WriteByte(':')
+		sb.WriteString(b.String())
+	}
+	return sb.String()
+}
+
+// Class is the signature of a class or interface.
+type Class struct {
+	TypeParameters []TypeParameter
+	Superclass     ClassType
+	Interfaces     []ClassType
+}
+
+func (c Class) String() string {
+	var sb strings.Builder
+	writeTypeParameters(&sb, c.TypeParameters)
+	sb.WriteString(c.Superclass.String())
+	for _, i := range c.Interfaces {
+		sb.WriteString(i.String())
+	}
+	return sb.String()
+}
+
+// Method is the signature of a method. Result is descriptor.Void for methods
+// that do not return a value, and Throws holds ClassTypes and TypeVariables.
+type Method struct {
+	TypeParameters []TypeParameter
+	Params         []Type
+	Result         Type
+	Throws         []Type
+}
+
+func (m Method) String() string {
+	var sb strings.Builder
+	writeTypeParameters(&sb, m.TypeParameters)
+	sb.WriteByte('(')
+	for _, p := range m.Params {
+		sb.WriteString(p.String())
+	}
+	sb.WriteByte(')')
+	sb.WriteString(m.Result.String())
+	for _, t := range m.Throws {
+		sb.WriteByte('^')
+		sb.WriteString(t.String())
+	}
+	return sb.String()
+}
+
+func writeTypeParameters(sb *strings.Builder, params []TypeParameter) {
+	if len(params) == 0 {
+		return
+	}
+	sb.WriteByte('<')
+	for _, p := range params {
+		sb.WriteString(p.String())
+	}
+	sb.WriteByte('>')
+}
+
+// ParseClass parses a class signature, such as
+// <T:Ljava/lang/Object;>Ljava/lang/Object;Ljava/lang/Comparable<TT;>;.
+func ParseClass(s string) (Class, error) {
+	p := parser{s: s}
+	var (
+		c   Class
+		err error
+	)
+	if c.TypeParameters, err = p.typeParameters(); err != nil {
+		return Class{}, err
+	}
+	if c.Superclass, err = p.classType(); err != nil {
+		return Class{}, err
+	}
+	for p.pos < len(p.s) {
+		i, err := p.classType()
+		if err != nil {
+			return Class{}, err
+		}
+		c.Interfaces = append(c.Interfaces, i)
+	}
+	return c, nil
+}
+
+// ParseMethod parses a method signature, such as
+// <T:Ljava/lang/Object;>(Ljava/util/List<+TT;>;)TT;^TE;.
+func ParseMethod(s string) (Method, error) {
+	p := parser{s: s}
+	var (
+		m   Method
+		err error
+	)
+	if m.TypeParameters, err = p.typeParameters(); err != nil {
+		return Method{}, err
+	}
+	if !p.consume('(') {
+		return Method{}, ErrInvalidSignature
+	}
+	for !p.consume(')') {
+		t, err := p.javaType()
+		if err != nil {
+			return Method{}, err
+		}
+		m.Params = append(m.Params, t)
+	}
+	if p.consume('V') {
+		m.Result = descriptor.Void
+	} else if m.Result, err = p.javaType(); err != nil {
+		return Method{}, err
+	}
+	for p.consume('^') {
+		var t Type
+		if p.peek() == 'T' {
+			t, err = p.typeVariable()
+		} else {
+			t, err = p.classType()
+		}
+		if err != nil {
+			return Method{}, err
+		}
+		m.Throws = append(m.Throws, t)
+	}
+	if p.pos != len(p.s) {
+		return Method{}, ErrInvalidSignature
+	}
+	return m, nil
+}
+
+// ParseField parses a field signature, such as
+// Ljava/util/Map<TK;+Ljava/util/List<TV;>;>;.
+func ParseField(s string) (Type, error) {
+	p := parser{s: s}
+	t, err := p.referenceType()
+	if err != nil {
+		return nil, err
+	} else if p.pos != len(p.s) {
+		return nil, ErrInvalidSignature
+	}
+	return t, nil
+}
+
+type parser struct {
+	s   string
+	pos int
+}
+
+func (p *parser) peek() byte {
+	if p.pos < len(p.s) {
+		return p.s[p.pos]
+	}
+	return 0
+}
+
+func (p *parser) consume(c byte) bool {
+	if p.peek() == c {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *parser) identifier() (string, error) {
+	start := p.pos
+	for p.pos < len(p.s) && !strings.ContainsRune(".;[/<>:", rune(p.s[p.pos])) {
+		p.pos++
+	}
+	if p.pos == start {
+		return "", ErrInvalidSignature
+	}
+	return p.s[start:p.pos], nil
+}
+
+func (p *parser) typeParameters() ([]TypeParameter, error) {
+	if !p.consume('<') {
+		return nil, nil
+	}
+	var params []TypeParameter
+	for !p.consume('>') {
+		var (
+			t   TypeParameter
+			err error
+		)
+		if t.Name, err = p.identifier(); err != nil {
+			return nil, err
+		}
+		if !p.consume(':') {
+			return nil, ErrInvalidSignature
+		}
+		if c := p.peek(); c == 'L' || c == 'T' || c == '[' {
+			if t.ClassBound, err = p.referenceType(); err != nil {
+				return nil, err
+			}
+		}
+		for p.consume(':') {
+			b, err := p.referenceType()
+			if err != nil {
+				return nil, err
+			}
+			t.InterfaceBounds = append(t.InterfaceBounds, b)
+		}
+		params = append(params, t)
+	}
+	if len(params) == 0 {
+		return nil, ErrInvalidSignature
+	}
+	return params, nil
+}
+
+func (p *parser) javaType() (Type, error) {
+	switch b := descriptor.BaseType(p.peek()); b {
+	case descriptor.Byte, descriptor.Char, descriptor.Double, descriptor.Float, descriptor.Int, descriptor.Long, descriptor.Short, descriptor.Boolean:
+		p.pos++
+		return b, nil
+	}
+	return p.referenceType()
+}
+
+func (p *parser) referenceType() (Type, error) {
+	switch p.peek() {
+	case 'L':
+		return p.classType()
+	case 'T':
+		return p.typeVariable()
+	case '[':
+		p.pos++
+		elem, err := p.javaType()
+		if err != nil {
+			return nil, err
+		}
+		return ArrayType{Elem: elem}, nil
+	}
+	return nil, ErrInvalidSignature
+}
+
+func (p *parser) typeVariable() (Type, error) {
+	if !p.consume('T') {
+		return nil, ErrInvalidSignature
+	}
+	name, err := p.identifier()
+	if err != nil {
+		return nil, err
+	} else if !p.consume(';') {
+		return nil, ErrInvalidSignature
+	}
+	return TypeVariable{Name: name}, nil
+}
+
+func (p *parser) classType() (ClassType, error) {
+	if !p.consume('L') {
+		return ClassType{}, ErrInvalidSignature
+	}
+	var c ClassType
+	name, err := p.identifier()
+	if err != nil {
+		return ClassType{}, err
+	}
+	for p.consume('/') {
+		if c.Package != "" {
+			c.Package += "/"
+		}
+		c.Package += name
+		if name, err = p.identifier(); err != nil {
+			return ClassType{}, err
+		}
+	}
+	for {
+		s := SimpleClassType{Name: name}
+		if p.consume('<') {
+			for !p.consume('>') {
+				a, err := p.typeArgument()
+				if err != nil {
+					return ClassType{}, err
+				}
+				s.TypeArguments = append(s.TypeArguments, a)
+			}
+			if len(s.TypeArguments) == 0 {
+				return ClassType{}, ErrInvalidSignature
+			}
+		}
+		c.Classes = append(c.Classes, s)
+		if p.consume(';') {
+			return c, nil
+		} else if !p.consume('.') {
+			return ClassType{}, ErrInvalidSignature
+		}
+		if name, err = p.identifier(); err != nil {
+			return ClassType{}, err
+		}
+	}
+}
+
+func (p *parser) typeArgument() (TypeArgument, error) {
+	var a TypeArgument
+	switch w := Wildcard(p.peek()); w {
+	case Unbounded:
+		p.pos++
+		return TypeArgument{Wildcard: Unbounded}, nil
+	case Extends, Super:
+		p.pos++
+		a.Wildcard = w
+	}
+	t, err := p.referenceType()
+	if err != nil {
+		return TypeArgument{}, err
+	}
+	a.Type = t
+	return a, nil
+}
+
+//Errors
+
+var ErrInvalidSignature = errors.New("invalid signature")
@@ -0,0 +1,149 @@
+package signature
+
+import (
+	"reflect"
+	"testing"
+
+	"vimagination.zapto.org/javaclass/descriptor"
+)
+
+func TestParseField(t *testing.T) {
+	for n, test := range [...]struct {
+		Input, Qualified, Simple string
+		Type                     Type
+		Err                      error
+	}{
+		{
+			Input:     "Ljava/lang/String;",
+			Qualified: "java.lang.String",
+			Simple:    "String",
+			Type:      ClassType{"java/lang", []SimpleClassType{{Name: "String"}}},
+		},
+		{
+			Input:     "TT;",
+			Qualified: "T",
+			Simple:    "T",
+			Type:      TypeVariable{"T"},
+		},
+		{
+			Input:     "[[TT;",
+			Qualified: "T[][]",
+			Simple:    "T[][]",
+			Type:      ArrayType{ArrayType{TypeVariable{"T"}}},
+		},
+		{
+			Input:     "[I",
+			Qualified: "int[]",
+			Simple:    "int[]",
+			Type:      ArrayType{descriptor.Int},
+		},
+		{
+			Input:     "Ljava/util/Map<TK;+Ljava/util/List<TV;>;>;",
+			Qualified: "java.util.Map<K, ? extends java.util.List<V>>",
+			Simple:    "Map<K, ? extends List<V>>",
+			Type: ClassType{"java/util", []SimpleClassType{{"Map", []TypeArgument{
+				{Type: TypeVariable{"K"}},
+				{Extends, ClassType{"java/util", []SimpleClassType{{"List", []TypeArgument{{Type: TypeVariable{"V"}}}}}}},
+			}}}},
+		},
+		{
+			Input:     "Ljava/lang/Class<*>;",
+			Qualified: "java.lang.Class<?>",
+			Simple:    "Class<?>",
+			Type:      ClassType{"java/lang", []SimpleClassType{{"Class", []TypeArgument{{Wildcard: Unbounded}}}}},
+		},
+		{
+			Input:     "LOuter<-[I>.Inner<TT;>.Map$Entry;",
+			Qualified: "Outer<? super int[]>.Inner<T>.Map.Entry",
+			Simple:    "Outer<? super int[]>.Inner<T>.Map.Entry",
+			Type: ClassType{"", []SimpleClassType{
+				{"Outer", []TypeArgument{{Super, ArrayType{descriptor.Int}}}},
+				{"Inner", []TypeArgument{{Type: TypeVariable{"T"}}}},
+				{Name: "Map$Entry"},
+			}},
+		},
+		{Input: "I", Err: ErrInvalidSignature},
+		{Input: "", Err: ErrInvalidSignature},
+		{Input: "TT", Err: ErrInvalidSignature},
+		{Input: "Ljava/lang/String", Err: ErrInvalidSignature},
+		{Input: "Ljava//String;", Err: ErrInvalidSignature},
+		{Input: "LList<>;", Err: ErrInvalidSignature},
+		{Input: "LList<I>;", Err: ErrInvalidSignature},
+		{Input: "LA;LB;", Err: ErrInvalidSignature},
+		{Input: "LA.;", Err: ErrInvalidSignature},
+	} {
+		typ, err := ParseField(test.Input)
+		if err != test.Err {
+			t.Errorf("test %d: expecting error %v, got %v", n+1, test.Err, err)
+		} else if !reflect.DeepEqual(typ, test.Type) {
+			t.Errorf("test %d: expecting type %#v, got %#v", n+1, test.Type, typ)
+		} else if err == nil {
+			if s := typ.String(); s != test.Input {
+				t.Errorf("test %d: expecting signature %q, got %q", n+1, test.Input, s)
+			}
+			if s := Source(typ, true); s != test.Qualified {
+				t.Errorf("test %d: expecting qualified source %q, got %q", n+1, test.Qualified, s)
+			}
+			if s := Source(typ, false); s != test.Simple {
+				t.Errorf("test %d: expecting simple source %q, got %q", n+1, test.Simple, s)
+			}
+		}
+	}
+}
+
+func TestParseClass(t *testing.T) {
+	for n, test := range [...]struct {
+		Input, Source string
+		Err           error
+	}{
+		{"Ljava/lang/Object;", "C extends Object", nil},
+		{"<T:Ljava/lang/Object;>Ljava/lang/Object;Ljava/lang/Comparable<TT;>;", "C<T> extends Object implements Comparable<T>", nil},
+		{"<K::Ljava/lang/Comparable<TK;>;V:Ljava/lang/Number;:Ljava/io/Serializable;>Ljava/util/AbstractMap<TK;TV;>;Ljava/util/Map<TK;TV;>;Ljava/lang/Cloneable;", "C<K extends Comparable<K>, V extends Number & Serializable> extends AbstractMap<K, V> implements Map<K, V>, Cloneable", nil},
+		{"", "", ErrInvalidSignature},
+		{"<>Ljava/lang/Object;", "", ErrInvalidSignature},
+		{"<T>Ljava/lang/Object;", "", ErrInvalidSignature},
+		{"TT;", "", ErrInvalidSignature},
+		{"Ljava/lang/Object;I", "", ErrInvalidSignature},
+	} {
+		c, err := ParseClass(test.Input)
+		if err != test.Err {
+			t.Errorf("test %d: expecting error %v, got %v", n+1, test.Err, err)
+		} else if err == nil {
+			if s := c.String(); s != test.Input {
+				t.Errorf("test %d: expecting signature %q, got %q", n+1, test.Input, s)
+			}
+			if s := c.Source("C", false); s != test.Source {
+				t.Errorf("test %d: expecting source %q, got %q", n+1, test.Source, s)
+			}
+		}
+	}
+}
+
+func TestParseMethod(t *testing.T) {
+	for n, test := range [...]struct {
+		Input, Source string
+		Err           error
+	}{
+		{"()V", "void m()", nil},
+		{"(IJ[Ljava/lang/String;)Z", "boolean m(int, long, java.lang.String[])", nil},
+		{"<T:Ljava/lang/Object;E:Ljava/lang/Exception;>(Ljava/util/List<+TT;>;)TT;^TE;^Ljava/io/IOException;", "<T, E extends java.lang.Exception> T m(java.util.List<? extends T>) throws E, java.io.IOException", nil},
+		{"", "", ErrInvalidSignature},
+		{"()", "", ErrInvalidSignature},
+		{"(V)V", "", ErrInvalidSignature},
+		{"()VV", "", ErrInvalidSignature},
+		{"()V^I", "", ErrInvalidSignature},
+		{"(I", "", ErrInvalidSignature},
+	} {
+		m, err := ParseMethod(test.Input)
+		if err != test.Err {
+			t.Errorf("test %d: expecting error %v, got %v", n+1, test.Err, err)
+		} else if err == nil {
+			if s := m.String(); s != test.Input {
+				t.Errorf("test %d: expecting signature %q, got %q", n+1, test.Input, s)
+			}
+			if s := m.Source("m", true); s != test.Source {
+				t.Errorf("test %d: expecting source %q, got %q", n+1, test.Source, s)
+			}
+		}
+	}
+}
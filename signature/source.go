@@ -0,0 +1,139 @@
+package signature
+
+import (
+	"strings"
+
+	"vimagination.zapto.org/javaclass/descriptor"
+)
+
+// Source returns t as it would be written in Java source, such as
+// Map<K, ? extends List<V>>. Class names are prefixed with their package
+// when qualified is true.
+func Source(t Type, qualified bool) string {
+	var sb strings.Builder
+	writeSource(&sb, t, qualified)
+	return sb.String()
+}
+
+func writeSource(sb *strings.Builder, t Type, qualified bool) {
+	switch t := t.(type) {
+	case descriptor.BaseType:
+		sb.WriteString(t.Source())
+	case ClassType:
+		if qualified && t.Package != "" {
+			sb.WriteString(descriptor.BinaryName(t.Package))
+			sb.WriteByte('.')
+		}
+		for n, s := range t.Classes {
+			if n > 0 {
+				sb.WriteByte('.')
+			}
+			sb.WriteString(descriptor.SourceName(s.Name))
+			if len(s.TypeArguments) > 0 {
+				sb.WriteByte('<')
+				for m, a := range s.TypeArguments {
+					if m > 0 {
+						sb.WriteString(", ")
+					}
+					switch a.Wildcard {
+					case Unbounded:
+						sb.WriteByte('?')
+						continue
+					case Extends:
+						sb.WriteString("? extends ")
+					case Super:
+						sb.WriteString("? super ")
+					}
+					writeSource(sb, a.Type, qualified)
+				}
+				sb.WriteByte('>')
+			}
+		}
+	case TypeVariable:
+		sb.WriteString(t.Name)
+	case ArrayType:
+		writeSource(sb, t.Elem, qualified)
+		sb.WriteString("[]")
+	}
+}
+
+func writeTypeParametersSource(sb *strings.Builder, params []TypeParameter, qualified bool) {
+	if len(params) == 0 {
+		return
+	}
+	sb.WriteByte('<')
+	for n, p := range params {
+		if n > 0 {
+			sb.WriteString(", ")
+		}
+		sb.WriteString(p.Name)
+		bounds := p.InterfaceBounds
+		if p.ClassBound != nil && (len(bounds) > 0 || !isObject(p.ClassBound)) {
+			bounds = append([]Type{p.ClassBound}, bounds...)
+		}
+		for m, b := range bounds {
+			if m == 0 {
+				sb.WriteString(" extends ")
+			} else {
+				sb.WriteString(" & ")
+			}
+			writeSource(sb, b, qualified)
+		}
+	}
+	sb.WriteByte('>')
+}
+
+func isObject(t Type) bool {
+	c, ok := t.(ClassType)
+	return ok && c.Package == "java/lang" && len(c.Classes) == 1 && c.Classes[0].Name == "Object" && len(c.Classes[0].TypeArguments) == 0
+}
+
+// Source returns the Java source declaration of a class with the given name
+// and this signature, such as
+// Foo<T extends Comparable<T>> extends Object implements List<T>.
+func (c Class) Source(name string, qualified bool) string {
+	var sb strings.Builder
+	sb.WriteString(name)
+	writeTypeParametersSource(&sb, c.TypeParameters, qualified)
+	sb.WriteString(" extends ")
+	writeSource(&sb, c.Superclass, qualified)
+	for n, i := range c.Interfaces {
+		if n == 0 {
+			sb.WriteString(" implements ")
+		} else {
+			sb.WriteString(", ")
+		}
+		writeSource(&sb, i, qualified)
+	}
+	return sb.String()
+}
+
+// Source returns the Java source declaration of a method with the given name
+// and this signature, such as <T> T max(List<? extends T>) throws E.
+func (m Method) Source(name string, qualified bool) string {
+	var sb strings.Builder
+	if len(m.TypeParameters) > 0 {
+		writeTypeParametersSource(&sb, m.TypeParameters, qualified)
+		sb.WriteByte(' ')
+	}
+	writeSource(&sb, m.Result, qualified)
+	sb.WriteByte(' ')
+	sb.WriteString(name)
+	sb.WriteByte('(')
+	for n, p := range m.Params {
+		if n > 0 {
+			sb.WriteString(", ")
+		}
+		writeSource(&sb, p, qualified)
+	}
+	sb.WriteByte(')')
+	for n, t := range m.Throws {
+		if n == 0 {
+			sb.WriteString(" throws ")
+		} else {
+			sb.WriteString(", ")
+		}
+		writeSource(&sb, t, qualified)
+	}
+	return sb.String()
+}
@@ -1,6 +1,7 @@
 package javaclass
 
 import (
+	"errors"
 	"io"
 
 	"vimagination.zapto.org/byteio"
@@ -20,8 +21,8 @@ type StackMapFrame interface {
 	FrameType() uint8
 }
 
-func readStackMapFrame(r io.Reader) (StackMapFrame, error) {
-	br := byteio.BigEndianReader{Reader: r}
+func (d *decoder) readStackMapFrame(r io.Reader) (StackMapFrame, error) {
+	br := binaryReader{r}
 	frameType, _, err := br.ReadUint8()
 	if err != nil {
 		return nil, err
@@ -32,6 +33,8 @@ func readStackMapFrame(r io.Reader) (StackMapFrame, error) {
 		stackMapFrame, err = readSameFrame(r, frameType)
 	case frameType <= FrameMaxSameLocals:
 		stackMapFrame, err = readSameLocalsFrame(r, frameType)
+	case frameType < FrameSameLocals1StackItemExtended:
+		err = valueError(ErrUnknownFrameType, int64(frameType))
 	case frameType == FrameSameLocals1StackItemExtended:
 		stackMapFrame, err = readSameLocals1StackItemExtendedFrame(r, frameType)
 	case frameType <= FrameMaxChop:
@@ -39,9 +42,9 @@ func readStackMapFrame(r io.Reader) (StackMapFrame, error) {
 	case frameType == FrameSameExtended:
 		stackMapFrame, err = readSameExtendedFrame(r, frameType)
 	case frameType <= FrameMaxAppend:
-		stackMapFrame, err = readAppendFrame(r, frameType)
+		stackMapFrame, err = d.readAppendFrame(r, frameType)
 	case frameType == FrameFull:
-		stackMapFrame, err = readFullFrame(r, frameType)
+		stackMapFrame, err = d.readFullFrame(r, frameType)
 	}
 	if err != nil {
 		return nil, err
@@ -49,6 +52,68 @@ func readStackMapFrame(r io.Reader) (StackMapFrame, error) {
 	return stackMapFrame, nil
 }
 
+func writeStackMapFrame(w io.Writer, stackMapFrame StackMapFrame) error {
+	if stackMapFrame == nil {
+		return ErrUnknownFrameType
+	}
+	bw := byteio.BigEndianWriter{Writer: w}
+	if _, err := bw.WriteUint8(stackMapFrame.FrameType()); err != nil {
+		return err
+	}
+	switch s := stackMapFrame.(type) {
+	case SameFrame:
+		return nil
+	case SameLocals1StackItemFrame:
+		return writeVerificationTypeInfo(w, s.Stack)
+	case SameLocals1StackItemFrameExtended:
+		if _, err := bw.WriteUint16(s.OffsetDelta); err != nil {
+			return err
+		}
+		return writeVerificationTypeInfo(w, s.Stack)
+	case ChopFrame:
+		_, err := bw.WriteUint16(s.OffsetDelta)
+		return err
+	case SameFrameExtended:
+		_, err := bw.WriteUint16(s.OffsetDelta)
+		return err
+	case AppendFrame:
+		if len(s.Locals) != int(s.frameType)-251 {
+			return ErrUnknownFrameType
+		}
+		if _, err := bw.WriteUint16(s.OffsetDelta); err != nil {
+			return err
+		}
+		for _, local := range s.Locals {
+			if err := writeVerificationTypeInfo(w, local); err != nil {
+				return err
+			}
+		}
+		return nil
+	case FullFrame:
+		if _, err := bw.WriteUint16(s.OffsetDelta); err != nil {
+			return err
+		}
+		if err := writeLength(w, len(s.Locals)); err != nil {
+			return err
+		}
+		for _, local := range s.Locals {
+			if err := writeVerificationTypeInfo(w, local); err != nil {
+				return err
+			}
+		}
+		if err := writeLength(w, len(s.Stack)); err != nil {
+			return err
+		}
+		for _, stack := range s.Stack {
+			if err := writeVerificationTypeInfo(w, stack); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return ErrUnknownFrameType
+}
+
 type SameFrame struct {
 	frameType uint8
 }
@@ -84,7 +149,7 @@ type SameLocals1StackItemFrameExtended struct {
 }
 
 func readSameLocals1StackItemExtendedFrame(r io.Reader, _ uint8) (StackMapFrame, error) {
-	br := byteio.BigEndianReader{Reader: r}
+	br := binaryReader{r}
 	offsetDelta, _, err := br.ReadUint16()
 	if err != nil {
 		return nil, err
@@ -109,7 +174,7 @@ type ChopFrame struct {
 }
 
 func readChopFrame(r io.Reader, frameType uint8) (StackMapFrame, error) {
-	br := byteio.BigEndianReader{Reader: r}
+	br := binaryReader{r}
 	offsetDelta, _, err := br.ReadUint16()
 	if err != nil {
 		return nil, err
@@ -126,7 +191,7 @@ type SameFrameExtended struct {
 }
 
 func readSameExtendedFrame(r io.Reader, _ uint8) (StackMapFrame, error) {
-	br := byteio.BigEndianReader{Reader: r}
+	br := binaryReader{r}
 	offsetDelta, _, err := br.ReadUint16()
 	if err != nil {
 		return nil, err
@@ -144,17 +209,20 @@ type AppendFrame struct {
 	Locals      []VerificationTypeInfo
 }
 
-func readAppendFrame(r io.Reader, frameType uint8) (StackMapFrame, error) {
-	br := byteio.BigEndianReader{Reader: r}
+func (d *decoder) readAppendFrame(r io.Reader, frameType uint8) (StackMapFrame, error) {
+	br := binaryReader{r}
 	offsetDelta, _, err := br.ReadUint16()
 	if err != nil {
 		return nil, err
 	}
+	if err := reserve[VerificationTypeInfo](d, r, int(frameType-251), 1); err != nil {
+		return nil, err
+	}
 	locals := make([]VerificationTypeInfo, frameType-251)
 	for i := uint8(0); i < frameType-251; i++ {
 		locals[i], err = readVerificationTypeInfo(r)
 		if err != nil {
-			return nil, err
+			return nil, indexError(err, "locals", int(i))
 		}
 	}
 	return AppendFrame{frameType, offsetDelta, locals}, nil
@@ -169,8 +237,8 @@ type FullFrame struct {
 	Locals, Stack []VerificationTypeInfo
 }
 
-func readFullFrame(r io.Reader, _ uint8) (StackMapFrame, error) {
-	br := byteio.BigEndianReader{Reader: r}
+func (d *decoder) readFullFrame(r io.Reader, _ uint8) (StackMapFrame, error) {
+	br := binaryReader{r}
 	offsetDelta, _, err := br.ReadUint16()
 	if err != nil {
 		return nil, err
@@ -179,22 +247,28 @@ func readFullFrame(r io.Reader, _ uint8) (StackMapFrame, error) {
 	if err != nil {
 		return nil, err
 	}
+	if err := reserve[VerificationTypeInfo](d, r, int(numberOfLocals), 1); err != nil {
+		return nil, err
+	}
 	locals := make([]VerificationTypeInfo, numberOfLocals)
 	for i := uint16(0); i < numberOfLocals; i++ {
 		locals[i], err = readVerificationTypeInfo(r)
 		if err != nil {
-			return nil, err
+			return nil, indexError(err, "locals", int(i))
 		}
 	}
 	numberOfStackItems, _, err := br.ReadUint16()
 	if err != nil {
 		return nil, err
 	}
+	if err := reserve[VerificationTypeInfo](d, r, int(numberOfStackItems), 1); err != nil {
+		return nil, err
+	}
 	stack := make([]VerificationTypeInfo, numberOfStackItems)
 	for i := uint16(0); i < numberOfStackItems; i++ {
 		stack[i], err = readVerificationTypeInfo(r)
 		if err != nil {
-			return nil, err
+			return nil, indexError(err, "stack", int(i))
 		}
 	}
 	return FullFrame{
@@ -207,3 +281,7 @@ func readFullFrame(r io.Reader, _ uint8) (StackMapFrame, error) {
 func (FullFrame) FrameType() uint8 {
 	return FrameFull
 }
+
+//Errors
+
+var ErrUnknownFrameType = errors.New("unknown stack map frame type")
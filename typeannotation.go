@@ -0,0 +1,468 @@
+package javaclass
+
+import (
+	"errors"
+	"io"
+	"math"
+
+	"vimagination.zapto.org/byteio"
+)
+
+const (
+	TargetClassTypeParameter                = 0x00
+	TargetMethodTypeParameter               = 0x01
+	TargetSupertype                         = 0x10
+	TargetClassTypeParameterBound           = 0x11
+	TargetMethodTypeParameterBound          = 0x12
+	TargetField                             = 0x13
+	TargetReturn                            = 0x14
+	TargetReceiver                          = 0x15
+	TargetFormalParameter                   = 0x16
+	TargetThrows                            = 0x17
+	TargetLocalVariable                     = 0x40
+	TargetResourceVariable                  = 0x41
+	TargetExceptionParameter                = 0x42
+	TargetInstanceOf                        = 0x43
+	TargetNew                               = 0x44
+	TargetConstructorReference              = 0x45
+	TargetMethodReference                   = 0x46
+	TargetCast                              = 0x47
+	TargetConstructorInvocationTypeArgument = 0x48
+	TargetMethodInvocationTypeArgument      = 0x49
+	TargetConstructorReferenceTypeArgument  = 0x4A
+	TargetMethodReferenceTypeArgument       = 0x4B
+)
+
+const (
+	TypePathArray        = 0
+	TypePathNested       = 1
+	TypePathWildcard     = 2
+	TypePathTypeArgument = 3
+)
+
+type TargetInfo interface {
+	TargetType() uint8
+}
+
+type TypePathEntry struct {
+	TypePathKind, TypeArgumentIndex uint8
+}
+
+type TypeAnnotation struct {
+	TargetInfo        TargetInfo
+	TargetPath        []TypePathEntry
+	TypeIndex         uint16
+	ElementValuePairs []ElementValuePair
+}
+
+func (d *decoder) readTypeAnnotation(r io.Reader) (TypeAnnotation, error) {
+	br := binaryReader{r}
+	targetType, _, err := br.ReadUint8()
+	if err != nil {
+		return TypeAnnotation{}, err
+	}
+	var targetInfo TargetInfo
+	switch targetType {
+	case TargetClassTypeParameter, TargetMethodTypeParameter:
+		targetInfo, err = readTypeParameterTarget(r, targetType)
+	case TargetSupertype:
+		targetInfo, err = readSupertypeTarget(r, targetType)
+	case TargetClassTypeParameterBound, TargetMethodTypeParameterBound:
+		targetInfo, err = readTypeParameterBoundTarget(r, targetType)
+	case TargetField, TargetReturn, TargetReceiver:
+		targetInfo = EmptyTarget{targetType}
+	case TargetFormalParameter:
+		targetInfo, err = readFormalParameterTarget(r, targetType)
+	case TargetThrows:
+		targetInfo, err = readThrowsTarget(r, targetType)
+	case TargetLocalVariable, TargetResourceVariable:
+		targetInfo, err = d.readLocalVarTarget(r, targetType)
+	case TargetExceptionParameter:
+		targetInfo, err = readCatchTarget(r, targetType)
+	case TargetInstanceOf, TargetNew, TargetConstructorReference, TargetMethodReference:
+		targetInfo, err = readOffsetTarget(r, targetType)
+	case TargetCast, TargetConstructorInvocationTypeArgument, TargetMethodInvocationTypeArgument, TargetConstructorReferenceTypeArgument, TargetMethodReferenceTypeArgument:
+		targetInfo, err = readTypeArgumentTarget(r, targetType)
+	default:
+		err = valueError(ErrUnknownTargetType, int64(targetType))
+	}
+	if err != nil {
+		return TypeAnnotation{}, err
+	}
+	targetPath, err := d.readTypePath(r)
+	if err != nil {
+		return TypeAnnotation{}, err
+	}
+	typeIndex, _, err := br.ReadUint16()
+	if err != nil {
+		return TypeAnnotation{}, err
+	}
+	elementValuePairs, err := d.readElementValuePairs(r)
+	if err != nil {
+		return TypeAnnotation{}, err
+	}
+	return TypeAnnotation{
+		TargetInfo:        targetInfo,
+		TargetPath:        targetPath,
+		TypeIndex:         typeIndex,
+		ElementValuePairs: elementValuePairs,
+	}, nil
+}
+
+func writeTypeAnnotation(w io.Writer, t TypeAnnotation) error {
+	if t.TargetInfo == nil {
+		return ErrUnknownTargetType
+	}
+	bw := byteio.BigEndianWriter{Writer: w}
+	if _, err := bw.WriteUint8(t.TargetInfo.TargetType()); err != nil {
+		return err
+	}
+	var err error
+	switch ti := t.TargetInfo.(type) {
+	case TypeParameterTarget:
+		_, err = bw.WriteUint8(ti.TypeParameterIndex)
+	case SupertypeTarget:
+		_, err = bw.WriteUint16(ti.SupertypeIndex)
+	case TypeParameterBoundTarget:
+		if _, err = bw.WriteUint8(ti.TypeParameterIndex); err == nil {
+			_, err = bw.WriteUint8(ti.BoundIndex)
+		}
+	case EmptyTarget:
+	case FormalParameterTarget:
+		_, err = bw.WriteUint8(ti.FormalParameterIndex)
+	case ThrowsTarget:
+		_, err = bw.WriteUint16(ti.ThrowsTypeIndex)
+	case LocalVarTarget:
+		err = writeLocalVarTarget(w, ti)
+	case CatchTarget:
+		_, err = bw.WriteUint16(ti.ExceptionTableIndex)
+	case OffsetTarget:
+		_, err = bw.WriteUint16(ti.Offset)
+	case TypeArgumentTarget:
+		if _, err = bw.WriteUint16(ti.Offset); err == nil {
+			_, err = bw.WriteUint8(ti.TypeArgumentIndex)
+		}
+	default:
+		err = ErrUnknownTargetType
+	}
+	if err != nil {
+		return err
+	}
+	if err := writeTypePath(w, t.TargetPath); err != nil {
+		return err
+	}
+	if _, err := bw.WriteUint16(t.TypeIndex); err != nil {
+		return err
+	}
+	return writeElementValuePairs(w, t.ElementValuePairs)
+}
+
+func (d *decoder) readTypeAnnotations(r io.Reader) ([]TypeAnnotation, error) {
+	br := binaryReader{r}
+	numAnnotations, _, err := br.ReadUint16()
+	if err != nil {
+		return nil, err
+	}
+	if err := reserve[TypeAnnotation](d, r, int(numAnnotations), 6); err != nil {
+		return nil, err
+	}
+	typeAnnotations := make([]TypeAnnotation, numAnnotations)
+	for i := uint16(0); i < numAnnotations; i++ {
+		typeAnnotations[i], err = d.readTypeAnnotation(r)
+		if err != nil {
+			return nil, indexError(err, "annotations", int(i))
+		}
+	}
+	return typeAnnotations, nil
+}
+
+func writeTypeAnnotations(w io.Writer, typeAnnotations []TypeAnnotation) error {
+	if err := writeLength(w, len(typeAnnotations)); err != nil {
+		return err
+	}
+	for _, typeAnnotation := range typeAnnotations {
+		if err := writeTypeAnnotation(w, typeAnnotation); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (d *decoder) readTypePath(r io.Reader) ([]TypePathEntry, error) {
+	br := binaryReader{r}
+	pathLength, _, err := br.ReadUint8()
+	if err != nil {
+		return nil, err
+	}
+	if err := reserve[TypePathEntry](d, r, int(pathLength), 2); err != nil {
+		return nil, err
+	}
+	path := make([]TypePathEntry, pathLength)
+	for i := uint8(0); i < pathLength; i++ {
+		typePathKind, _, err := br.ReadUint8()
+		if err != nil {
+			return nil, err
+		}
+		typeArgumentIndex, _, err := br.ReadUint8()
+		if err != nil {
+			return nil, err
+		}
+		path[i] = TypePathEntry{
+			TypePathKind:      typePathKind,
+			TypeArgumentIndex: typeArgumentIndex,
+		}
+	}
+	return path, nil
+}
+
+func writeTypePath(w io.Writer, path []TypePathEntry) error {
+	if len(path) > math.MaxUint8 {
+		return ErrTooLarge
+	}
+	bw := byteio.BigEndianWriter{Writer: w}
+	if _, err := bw.WriteUint8(uint8(len(path))); err != nil {
+		return err
+	}
+	for _, entry := range path {
+		if _, err := bw.WriteUint8(entry.TypePathKind); err != nil {
+			return err
+		}
+		if _, err := bw.WriteUint8(entry.TypeArgumentIndex); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type TypeParameterTarget struct {
+	targetType         uint8
+	TypeParameterIndex uint8
+}
+
+func readTypeParameterTarget(r io.Reader, targetType uint8) (TargetInfo, error) {
+	br := binaryReader{r}
+	typeParameterIndex, _, err := br.ReadUint8()
+	if err != nil {
+		return nil, err
+	}
+	return TypeParameterTarget{targetType, typeParameterIndex}, nil
+}
+
+func (t TypeParameterTarget) TargetType() uint8 {
+	return t.targetType
+}
+
+type SupertypeTarget struct {
+	SupertypeIndex uint16
+}
+
+func readSupertypeTarget(r io.Reader, _ uint8) (TargetInfo, error) {
+	br := binaryReader{r}
+	supertypeIndex, _, err := br.ReadUint16()
+	if err != nil {
+		return nil, err
+	}
+	return SupertypeTarget{supertypeIndex}, nil
+}
+
+func (SupertypeTarget) TargetType() uint8 {
+	return TargetSupertype
+}
+
+type TypeParameterBoundTarget struct {
+	targetType                     uint8
+	TypeParameterIndex, BoundIndex uint8
+}
+
+func readTypeParameterBoundTarget(r io.Reader, targetType uint8) (TargetInfo, error) {
+	br := binaryReader{r}
+	typeParameterIndex, _, err := br.ReadUint8()
+	if err != nil {
+		return nil, err
+	}
+	boundIndex, _, err := br.ReadUint8()
+	if err != nil {
+		return nil, err
+	}
+	return TypeParameterBoundTarget{
+		targetType:         targetType,
+		TypeParameterIndex: typeParameterIndex,
+		BoundIndex:         boundIndex,
+	}, nil
+}
+
+func (t TypeParameterBoundTarget) TargetType() uint8 {
+	return t.targetType
+}
+
+type EmptyTarget struct {
+	targetType uint8
+}
+
+func (e EmptyTarget) TargetType() uint8 {
+	return e.targetType
+}
+
+type FormalParameterTarget struct {
+	FormalParameterIndex uint8
+}
+
+func readFormalParameterTarget(r io.Reader, _ uint8) (TargetInfo, error) {
+	br := binaryReader{r}
+	formalParameterIndex, _, err := br.ReadUint8()
+	if err != nil {
+		return nil, err
+	}
+	return FormalParameterTarget{formalParameterIndex}, nil
+}
+
+func (FormalParameterTarget) TargetType() uint8 {
+	return TargetFormalParameter
+}
+
+type ThrowsTarget struct {
+	ThrowsTypeIndex uint16
+}
+
+func readThrowsTarget(r io.Reader, _ uint8) (TargetInfo, error) {
+	br := binaryReader{r}
+	throwsTypeIndex, _, err := br.ReadUint16()
+	if err != nil {
+		return nil, err
+	}
+	return ThrowsTarget{throwsTypeIndex}, nil
+}
+
+func (ThrowsTarget) TargetType() uint8 {
+	return TargetThrows
+}
+
+type LocalVarTargetEntry struct {
+	StartPC, Length, Index uint16
+}
+
+type LocalVarTarget struct {
+	targetType uint8
+	Table      []LocalVarTargetEntry
+}
+
+func (d *decoder) readLocalVarTarget(r io.Reader, targetType uint8) (TargetInfo, error) {
+	br := binaryReader{r}
+	tableLength, _, err := br.ReadUint16()
+	if err != nil {
+		return nil, err
+	}
+	if err := reserve[LocalVarTargetEntry](d, r, int(tableLength), 6); err != nil {
+		return nil, err
+	}
+	table := make([]LocalVarTargetEntry, tableLength)
+	for i := uint16(0); i < tableLength; i++ {
+		startPC, _, err := br.ReadUint16()
+		if err != nil {
+			return nil, err
+		}
+		length, _, err := br.ReadUint16()
+		if err != nil {
+			return nil, err
+		}
+		index, _, err := br.ReadUint16()
+		if err != nil {
+			return nil, err
+		}
+		table[i] = LocalVarTargetEntry{
+			StartPC: startPC,
+			Length:  length,
+			Index:   index,
+		}
+	}
+	return LocalVarTarget{targetType, table}, nil
+}
+
+func writeLocalVarTarget(w io.Writer, l LocalVarTarget) error {
+	if err := writeLength(w, len(l.Table)); err != nil {
+		return err
+	}
+	bw := byteio.BigEndianWriter{Writer: w}
+	for _, entry := range l.Table {
+		if _, err := bw.WriteUint16(entry.StartPC); err != nil {
+			return err
+		}
+		if _, err := bw.WriteUint16(entry.Length); err != nil {
+			return err
+		}
+		if _, err := bw.WriteUint16(entry.Index); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (l LocalVarTarget) TargetType() uint8 {
+	return l.targetType
+}
+
+type CatchTarget struct {
+	ExceptionTableIndex uint16
+}
+
+func readCatchTarget(r io.Reader, _ uint8) (TargetInfo, error) {
+	br := binaryReader{r}
+	exceptionTableIndex, _, err := br.ReadUint16()
+	if err != nil {
+		return nil, err
+	}
+	return CatchTarget{exceptionTableIndex}, nil
+}
+
+func (CatchTarget) TargetType() uint8 {
+	return TargetExceptionParameter
+}
+
+type OffsetTarget struct {
+	targetType uint8
+	Offset     uint16
+}
+
+func readOffsetTarget(r io.Reader, targetType uint8) (TargetInfo, error) {
+	br := binaryReader{r}
+	offset, _, err := br.ReadUint16()
+	if err != nil {
+		return nil, err
+	}
+	return OffsetTarget{targetType, offset}, nil
+}
+
+func (o OffsetTarget) TargetType() uint8 {
+	return o.targetType
+}
+
+type TypeArgumentTarget struct {
+	targetType        uint8
+	Offset            uint16
+	TypeArgumentIndex uint8
+}
+
+func readTypeArgumentTarget(r io.Reader, targetType uint8) (TargetInfo, error) {
+	br := binaryReader{r}
+	offset, _, err := br.ReadUint16()
+	if err != nil {
+		return nil, err
+	}
+	typeArgumentIndex, _, err := br.ReadUint8()
+	if err != nil {
+		return nil, err
+	}
+	return TypeArgumentTarget{
+		targetType:        targetType,
+		Offset:            offset,
+		TypeArgumentIndex: typeArgumentIndex,
+	}, nil
+}
+
+func (t TypeArgumentTarget) TargetType() uint8 {
+	return t.targetType
+}
+
+//Errors
+
+var ErrUnknownTargetType = errors.New("unknown type annotation target type")
@@ -0,0 +1,76 @@
+package javaclass
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestTypeAnnotationTargets(t *testing.T) {
+	rest := cat(list1(u1(TypePathTypeArgument, 1)), u2(7), list2())
+	for n, test := range [...]struct {
+		Data   []byte
+		Target TargetInfo
+	}{
+		{u1(TargetClassTypeParameter, 2), TypeParameterTarget{TargetClassTypeParameter, 2}},
+		{u1(TargetMethodTypeParameter, 3), TypeParameterTarget{TargetMethodTypeParameter, 3}},
+		{cat(u1(TargetSupertype), u2(0xffff)), SupertypeTarget{0xffff}},
+		{u1(TargetClassTypeParameterBound, 1, 2), TypeParameterBoundTarget{TargetClassTypeParameterBound, 1, 2}},
+		{u1(TargetMethodTypeParameterBound, 3, 4), TypeParameterBoundTarget{TargetMethodTypeParameterBound, 3, 4}},
+		{u1(TargetField), EmptyTarget{TargetField}},
+		{u1(TargetReturn), EmptyTarget{TargetReturn}},
+		{u1(TargetReceiver), EmptyTarget{TargetReceiver}},
+		{u1(TargetFormalParameter, 5), FormalParameterTarget{5}},
+		{cat(u1(TargetThrows), u2(6)), ThrowsTarget{6}},
+		{cat(u1(TargetLocalVariable), list2(u2(0, 10, 1))), LocalVarTarget{TargetLocalVariable, []LocalVarTargetEntry{{0, 10, 1}}}},
+		{cat(u1(TargetResourceVariable), list2(u2(2, 8, 3), u2(4, 6, 5))), LocalVarTarget{TargetResourceVariable, []LocalVarTargetEntry{{2, 8, 3}, {4, 6, 5}}}},
+		{cat(u1(TargetExceptionParameter), u2(1)), CatchTarget{1}},
+		{cat(u1(TargetInstanceOf), u2(10)), OffsetTarget{TargetInstanceOf, 10}},
+		{cat(u1(TargetNew), u2(11)), OffsetTarget{TargetNew, 11}},
+		{cat(u1(TargetConstructorReference), u2(12)), OffsetTarget{TargetConstructorReference, 12}},
+		{cat(u1(TargetMethodReference), u2(13)), OffsetTarget{TargetMethodReference, 13}},
+		{cat(u1(TargetCast), u2(14), u1(1)), TypeArgumentTarget{TargetCast, 14, 1}},
+		{cat(u1(TargetConstructorInvocationTypeArgument), u2(15), u1(2)), TypeArgumentTarget{TargetConstructorInvocationTypeArgument, 15, 2}},
+		{cat(u1(TargetMethodInvocationTypeArgument), u2(16), u1(3)), TypeArgumentTarget{TargetMethodInvocationTypeArgument, 16, 3}},
+		{cat(u1(TargetConstructorReferenceTypeArgument), u2(17), u1(4)), TypeArgumentTarget{TargetConstructorReferenceTypeArgument, 17, 4}},
+		{cat(u1(TargetMethodReferenceTypeArgument), u2(18), u1(5)), TypeArgumentTarget{TargetMethodReferenceTypeArgument, 18, 5}},
+	} {
+		data := cat(test.Data, rest)
+		d := decoder{Class: new(Class)}
+		ta, err := d.readTypeAnnotation(&sliceReader{data: data})
+		if err != nil {
+			t.Errorf("test %d: unexpected error: %s", n+1, err)
+			continue
+		}
+		if !reflect.DeepEqual(ta.TargetInfo, test.Target) {
+			t.Errorf("test %d: expecting target %#v, got %#v", n+1, test.Target, ta.TargetInfo)
+		} else if tt := ta.TargetInfo.TargetType(); tt != test.Data[0] {
+			t.Errorf("test %d: expecting target type %#x, got %#x", n+1, test.Data[0], tt)
+		} else if !reflect.DeepEqual(ta.TargetPath, []TypePathEntry{{TypePathTypeArgument, 1}}) || ta.TypeIndex != 7 {
+			t.Errorf("test %d: expecting path and type index to follow target, got %v and %d", n+1, ta.TargetPath, ta.TypeIndex)
+		}
+		var buf bytes.Buffer
+		if err := writeTypeAnnotation(&buf, TypeAnnotation{TargetInfo: test.Target, TargetPath: ta.TargetPath, TypeIndex: 7}); err != nil {
+			t.Errorf("test %d: unexpected error writing: %s", n+1, err)
+		} else if !bytes.Equal(buf.Bytes(), data) {
+			t.Errorf("test %d: expecting written bytes %x, got %x", n+1, data, buf.Bytes())
+		}
+	}
+}
+
+func TestUnknownTargetType(t *testing.T) {
+	for n, targetType := range [...]byte{0x02, 0x0f, 0x18, 0x3f, 0x4c, 0xff} {
+		d := decoder{Class: new(Class)}
+		_, err := d.readTypeAnnotation(&sliceReader{data: cat(u1(int(targetType)), u2(0, 0, 0, 0))})
+		var pe *ParseError
+		if !errors.Is(err, ErrUnknownTargetType) {
+			t.Errorf("test %d: expecting error %v, got %v", n+1, ErrUnknownTargetType, err)
+		} else if !errors.As(err, &pe) || pe.Value != int64(targetType) {
+			t.Errorf("test %d: expecting error value %d, got %v", n+1, targetType, err)
+		}
+	}
+	if err := writeTypeAnnotation(new(bytes.Buffer), TypeAnnotation{}); err != ErrUnknownTargetType {
+		t.Errorf("expecting error %v writing annotation without target, got %v", ErrUnknownTargetType, err)
+	}
+}
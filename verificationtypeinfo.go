@@ -24,7 +24,7 @@ type VerificationTypeInfo interface {
 }
 
 func readVerificationTypeInfo(r io.Reader) (VerificationTypeInfo, error) {
-	br := byteio.BigEndianReader{Reader: r}
+	br := binaryReader{r}
 	tag, _, err := br.ReadUint8()
 	if err != nil {
 		return nil, err
@@ -57,10 +57,31 @@ func readVerificationTypeInfo(r io.Reader) (VerificationTypeInfo, error) {
 		}
 		return UninitializedVariableInfo{offset}, nil
 	default:
-		return nil, ErrUnknownVerificationTypeTag
+		return nil, valueError(ErrUnknownVerificationTypeTag, int64(tag))
 	}
 }
 
+func writeVerificationTypeInfo(w io.Writer, verificationTypeInfo VerificationTypeInfo) error {
+	if verificationTypeInfo == nil {
+		return ErrUnknownVerificationTypeTag
+	}
+	bw := byteio.BigEndianWriter{Writer: w}
+	if _, err := bw.WriteUint8(uint8(verificationTypeInfo.Tag())); err != nil {
+		return err
+	}
+	switch v := verificationTypeInfo.(type) {
+	case TopVariableInfo, IntegerVariableInfo, FloatVariableInfo, DoubleVariableInfo, LongVariableInfo, NullVariableInfo, UninitializedThisVariableInfo:
+		return nil
+	case ObjectVariableInfo:
+		_, err := bw.WriteUint16(v.CPoolIndex)
+		return err
+	case UninitializedVariableInfo:
+		_, err := bw.WriteUint16(v.Offset)
+		return err
+	}
+	return ErrUnknownVerificationTypeTag
+}
+
 type TopVariableInfo struct{}
 
 func (TopVariableInfo) Tag() int {
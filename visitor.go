@@ -0,0 +1,615 @@
+package javaclass
+
+import "io"
+
+// ClassVisitor receives the contents of a class file as a sequence of events:
+// Visit, then VisitField and VisitMethod for each member, then the class
+// attributes, and finally VisitEnd.
+//
+// A visitor returning a nil FieldVisitor, MethodVisitor, CodeVisitor or
+// AnnotationVisitor is not sent the contents of that item, which Accept then
+// skips without decoding.
+//
+// Visitors can be chained by embedding the next visitor in the pipeline and
+// overriding only the methods of interest.
+type ClassVisitor interface {
+	Visit(minor, major uint16, constantPool []CPInfo, accessFlags ClassAccess, thisClass, superClass uint16, interfaces []uint16)
+	VisitField(accessFlags FieldAccess, nameIndex, descriptorIndex uint16) FieldVisitor
+	VisitMethod(accessFlags MethodAccess, nameIndex, descriptorIndex uint16) MethodVisitor
+	VisitAnnotation(typeIndex uint16, visible bool) AnnotationVisitor
+	VisitAttribute(a AttributeInfo)
+	VisitEnd()
+}
+
+// FieldVisitor receives the attributes of a field, followed by VisitEnd.
+type FieldVisitor interface {
+	VisitAnnotation(typeIndex uint16, visible bool) AnnotationVisitor
+	VisitAttribute(a AttributeInfo)
+	VisitEnd()
+}
+
+// MethodVisitor receives the attributes of a method, with the Code attribute
+// delivered through VisitCode, followed by VisitEnd.
+//
+// A Code attribute whose name is not the first CONSTANT_Utf8 entry holding
+// "Code" is instead passed to VisitAttribute, so that its AttributeNameIndex
+// is preserved.
+type MethodVisitor interface {
+	VisitAnnotation(typeIndex uint16, visible bool) AnnotationVisitor
+	VisitCode(maxStack, maxLocals uint16) CodeVisitor
+	VisitAttribute(a AttributeInfo)
+	VisitEnd()
+}
+
+// CodeVisitor receives the bytecode of a method, then each exception handler
+// and the attributes of the Code attribute, followed by VisitEnd.
+type CodeVisitor interface {
+	VisitBytecode(code []byte)
+	VisitExceptionHandler(e Exception)
+	VisitAttribute(a AttributeInfo)
+	VisitEnd()
+}
+
+// AnnotationVisitor receives the element value pairs of an annotation,
+// followed by VisitEnd.
+//
+// Nested annotations and arrays are delivered through VisitAnnotation and
+// VisitArray; all other values are passed to VisitValue. The elements of an
+// array are given a nameIndex of 0.
+//
+// The annotations in RuntimeVisibleAnnotations and
+// RuntimeInvisibleAnnotations attributes are delivered through
+// AnnotationVisitors, unless the attribute holds no annotations, or its name
+// is not the first CONSTANT_Utf8 entry holding it, in which case it is passed
+// to VisitAttribute.
+type AnnotationVisitor interface {
+	VisitValue(nameIndex uint16, value ElementValue)
+	VisitAnnotation(nameIndex, typeIndex uint16) AnnotationVisitor
+	VisitArray(nameIndex uint16) AnnotationVisitor
+	VisitEnd()
+}
+
+type attributeVisitor interface {
+	VisitAnnotation(typeIndex uint16, visible bool) AnnotationVisitor
+	VisitAttribute(a AttributeInfo)
+}
+
+// Accept reads a class file from r, using the default ReadOptions, and
+// delivers its contents to v.
+func Accept(r io.Reader, v ClassVisitor) error {
+	return ReadOptions{}.Accept(r, v)
+}
+
+// Accept reads a class file from r and delivers its contents to v.
+//
+// Each field and method is decoded, and its events delivered, before the next
+// is read, so no more than a single member is held in memory at once.
+//
+// Events may have been delivered before an error is encountered, which is
+// returned as a *ParseError.
+func (o ReadOptions) Accept(r io.Reader, v ClassVisitor) error {
+	cr := countReader{Reader: r}
+	d := decoder{
+		Class:       new(Class),
+		ReadOptions: o,
+	}
+	if err := d.accept(&cr, v); err != nil {
+		return parseError(err, cr.n)
+	}
+	return nil
+}
+
+func (d *decoder) accept(r io.Reader, v ClassVisitor) error {
+	if err := d.readHeader(r); err != nil {
+		return err
+	}
+	d.names = utf8Indexes(d.ConstantPool)
+	v.Visit(d.Minor, d.Major, d.ConstantPool, d.AccessFlags, d.ThisClass, d.SuperClass, d.Interfaces)
+	br := binaryReader{r}
+	fieldsCount, _, err := br.ReadUint16()
+	if err != nil {
+		return err
+	}
+	for i := uint16(0); i < fieldsCount; i++ {
+		if err := d.acceptField(r, v); err != nil {
+			return indexError(err, "fields", int(i))
+		}
+	}
+	methodsCount, _, err := br.ReadUint16()
+	if err != nil {
+		return err
+	}
+	for i := uint16(0); i < methodsCount; i++ {
+		if err := d.acceptMethod(r, v); err != nil {
+			return indexError(err, "methods", int(i))
+		}
+	}
+	if err := d.acceptAttributes(r, locationClass, v, nil); err != nil {
+		return err
+	}
+	v.VisitEnd()
+	return nil
+}
+
+func readMemberHeader(r io.Reader) (accessFlags, nameIndex, descriptorIndex uint16, err error) {
+	br := binaryReader{r}
+	if accessFlags, _, err = br.ReadUint16(); err != nil {
+		return 0, 0, 0, err
+	}
+	if nameIndex, _, err = br.ReadUint16(); err != nil {
+		return 0, 0, 0, err
+	}
+	if descriptorIndex, _, err = br.ReadUint16(); err != nil {
+		return 0, 0, 0, err
+	}
+	return accessFlags, nameIndex, descriptorIndex, nil
+}
+
+func (d *decoder) acceptField(r io.Reader, v ClassVisitor) error {
+	af, ni, di, err := readMemberHeader(r)
+	if err != nil {
+		return err
+	}
+	fv := v.VisitField(FieldAccess(af), ni, di)
+	if fv == nil {
+		return discardAttributes(r)
+	}
+	if err := d.acceptAttributes(r, locationField, fv, nil); err != nil {
+		return err
+	}
+	fv.VisitEnd()
+	return nil
+}
+
+func (d *decoder) acceptMethod(r io.Reader, v ClassVisitor) error {
+	af, ni, di, err := readMemberHeader(r)
+	if err != nil {
+		return err
+	}
+	mv := v.VisitMethod(MethodAccess(af), ni, di)
+	if mv == nil {
+		return discardAttributes(r)
+	}
+	if err := d.acceptAttributes(r, locationMethod, mv, mv); err != nil {
+		return err
+	}
+	mv.VisitEnd()
+	return nil
+}
+
+// acceptAttributes delivers an attributes table to v, passing any Code
+// attribute to mv when it is not nil.
+func (d *decoder) acceptAttributes(r io.Reader, location attributeLocation, v attributeVisitor, mv MethodVisitor) error {
+	br := binaryReader{r}
+	attributesCount, _, err := br.ReadUint16()
+	if err != nil {
+		return err
+	}
+	if err := reserve[struct{}](d, r, int(attributesCount), 6); err != nil {
+		return err
+	}
+	return d.scanAttributes(r, attributesCount, location, func(name string, nameIndex AttributeNameIndex, permitted bool, lr *io.LimitedReader) error {
+		if permitted && name == AttrCode && mv != nil && firstName(d.names, name, nameIndex) {
+			return d.acceptCode(lr, mv)
+		}
+		attributeInfo, err := d.readAttribute(name, nameIndex, permitted, lr)
+		if err != nil {
+			return err
+		}
+		visitAttribute(v, attributeInfo, d.names)
+		return nil
+	})
+}
+
+func (d *decoder) acceptCode(lr *io.LimitedReader, mv MethodVisitor) error {
+	br := binaryReader{lr}
+	maxStack, _, err := br.ReadUint16()
+	if err != nil {
+		return err
+	}
+	maxLocals, _, err := br.ReadUint16()
+	if err != nil {
+		return err
+	}
+	cv := mv.VisitCode(maxStack, maxLocals)
+	if cv == nil {
+		return discard(lr)
+	}
+	codeLength, _, err := br.ReadUint32()
+	if err != nil {
+		return err
+	}
+	if codeLength > d.maxCodeLength() {
+		return valueError(ErrCodeTooLong, int64(codeLength))
+	}
+	code, err := d.readBytes(lr, int(codeLength))
+	if err != nil {
+		return err
+	}
+	cv.VisitBytecode(code)
+	if err := d.acceptExceptionsTable(lr, cv); err != nil {
+		return pathError(err, "exception_table")
+	}
+	attributesCount, _, err := br.ReadUint16()
+	if err != nil {
+		return err
+	}
+	if err := reserve[struct{}](d, lr, int(attributesCount), 6); err != nil {
+		return err
+	}
+	if err := d.scanAttributes(lr, attributesCount, locationCode, func(name string, nameIndex AttributeNameIndex, permitted bool, lr *io.LimitedReader) error {
+		attributeInfo, err := d.readAttribute(name, nameIndex, permitted, lr)
+		if err != nil {
+			return err
+		}
+		cv.VisitAttribute(attributeInfo)
+		return nil
+	}); err != nil {
+		return err
+	}
+	cv.VisitEnd()
+	return nil
+}
+
+func (d *decoder) acceptExceptionsTable(r io.Reader, cv CodeVisitor) error {
+	br := binaryReader{r}
+	exceptionTableLength, _, err := br.ReadUint16()
+	if err != nil {
+		return err
+	}
+	if err := reserve[struct{}](d, r, int(exceptionTableLength), 8); err != nil {
+		return err
+	}
+	for i := uint16(0); i < exceptionTableLength; i++ {
+		var e Exception
+		if e.StartPC, _, err = br.ReadUint16(); err != nil {
+			return err
+		}
+		if e.EndPC, _, err = br.ReadUint16(); err != nil {
+			return err
+		}
+		if e.HandlerPC, _, err = br.ReadUint16(); err != nil {
+			return err
+		}
+		if e.CatchType, _, err = br.ReadUint16(); err != nil {
+			return err
+		}
+		cv.VisitExceptionHandler(e)
+	}
+	return nil
+}
+
+// discardAttributes skips an attributes table without decoding it.
+func discardAttributes(r io.Reader) error {
+	br := binaryReader{r}
+	attributesCount, _, err := br.ReadUint16()
+	if err != nil {
+		return err
+	}
+	for i := uint16(0); i < attributesCount; i++ {
+		if _, _, err := br.ReadUint16(); err != nil {
+			return indexError(err, "attributes", int(i))
+		}
+		attributeLength, _, err := br.ReadUint32()
+		if err != nil {
+			return indexError(err, "attributes", int(i))
+		}
+		if err := discard(&io.LimitedReader{R: r, N: int64(attributeLength)}); err != nil {
+			return indexError(err, "attributes", int(i))
+		}
+	}
+	return nil
+}
+
+// Accept delivers the contents of the Class to v, in the same way as Accept
+// would for its encoding.
+func (c *Class) Accept(v ClassVisitor) {
+	names := utf8Indexes(c.ConstantPool)
+	v.Visit(c.Minor, c.Major, c.ConstantPool, c.AccessFlags, c.ThisClass, c.SuperClass, c.Interfaces)
+	for _, f := range c.Fields {
+		if fv := v.VisitField(f.AccessFlags, f.NameIndex, f.DescriptorIndex); fv != nil {
+			for _, a := range f.Attributes {
+				visitAttribute(fv, a, names)
+			}
+			fv.VisitEnd()
+		}
+	}
+	for _, m := range c.Methods {
+		if mv := v.VisitMethod(m.AccessFlags, m.NameIndex, m.DescriptorIndex); mv != nil {
+			for _, a := range m.Attributes {
+				if code, ok := a.(CodeAttribute); ok && firstName(names, AttrCode, code.AttributeNameIndex) {
+					code.accept(mv)
+				} else {
+					visitAttribute(mv, a, names)
+				}
+			}
+			mv.VisitEnd()
+		}
+	}
+	for _, a := range c.Attributes {
+		visitAttribute(v, a, names)
+	}
+	v.VisitEnd()
+}
+
+func (c CodeAttribute) accept(mv MethodVisitor) {
+	cv := mv.VisitCode(c.MaxStack, c.MaxLocals)
+	if cv == nil {
+		return
+	}
+	cv.VisitBytecode(c.Code)
+	for _, e := range c.ExceptionTable {
+		cv.VisitExceptionHandler(e)
+	}
+	for _, a := range c.Attributes {
+		cv.VisitAttribute(a)
+	}
+	cv.VisitEnd()
+}
+
+// firstName reports whether an attribute is named by the first CONSTANT_Utf8
+// entry holding its name, as an attribute assembled by a ClassBuilder would be.
+func firstName(names map[string]uint16, name string, nameIndex AttributeNameIndex) bool {
+	return nameIndex == 0 || uint16(nameIndex) == names[name]
+}
+
+func visitAttribute(v attributeVisitor, a AttributeInfo, names map[string]uint16) {
+	switch a := a.(type) {
+	case RuntimeVisibleAnnotationsAttribute:
+		if len(a.Annotations) > 0 && firstName(names, a.Name(), a.AttributeNameIndex) {
+			visitAnnotations(v, a.Annotations, true)
+			return
+		}
+	case RuntimeInvisibleAnnotationsAttribute:
+		if len(a.Annotations) > 0 && firstName(names, a.Name(), a.AttributeNameIndex) {
+			visitAnnotations(v, a.Annotations, false)
+			return
+		}
+	}
+	v.VisitAttribute(a)
+}
+
+func visitAnnotations(v attributeVisitor, annotations []Annotation, visible bool) {
+	for _, a := range annotations {
+		if av := v.VisitAnnotation(a.TypeIndex, visible); av != nil {
+			visitElementValuePairs(av, a.ElementValuePairs)
+		}
+	}
+}
+
+func visitElementValuePairs(av AnnotationVisitor, pairs []ElementValuePair) {
+	for _, p := range pairs {
+		visitElementValue(av, p.ElementNameIndex, p.Value)
+	}
+	av.VisitEnd()
+}
+
+func visitElementValue(av AnnotationVisitor, nameIndex uint16, value ElementValue) {
+	switch value := value.(type) {
+	case AnnotationValue:
+		if nv := av.VisitAnnotation(nameIndex, value.Annotation.TypeIndex); nv != nil {
+			visitElementValuePairs(nv, value.Annotation.ElementValuePairs)
+		}
+	case ArrayValue:
+		if nv := av.VisitArray(nameIndex); nv != nil {
+			for _, v := range value.ArrayValues {
+				visitElementValue(nv, 0, v)
+			}
+			nv.VisitEnd()
+		}
+	default:
+		av.VisitValue(nameIndex, value)
+	}
+}
+
+// ClassBuilder is a ClassVisitor that builds a Class from the events it
+// receives.
+//
+// Attributes that it assembles from events, rather than receiving through
+// VisitAttribute, are named by the first CONSTANT_Utf8 entry holding their
+// name.
+type ClassBuilder struct {
+	class Class
+	attributeBuilder
+}
+
+// Class returns the Class built by the events received up to VisitEnd.
+func (b *ClassBuilder) Class() *Class {
+	return &b.class
+}
+
+func (b *ClassBuilder) Visit(minor, major uint16, constantPool []CPInfo, accessFlags ClassAccess, thisClass, superClass uint16, interfaces []uint16) {
+	b.class = Class{
+		Minor:        minor,
+		Major:        major,
+		ConstantPool: constantPool,
+		AccessFlags:  accessFlags,
+		ThisClass:    thisClass,
+		SuperClass:   superClass,
+		Interfaces:   interfaces,
+		Fields:       []FieldInfo{},
+		Methods:      []MethodInfo{},
+	}
+	b.attributeBuilder = attributeBuilder{
+		attributes: []AttributeInfo{},
+		names:      utf8Indexes(constantPool),
+	}
+}
+
+func (b *ClassBuilder) VisitField(accessFlags FieldAccess, nameIndex, descriptorIndex uint16) FieldVisitor {
+	return &fieldBuilder{
+		class: &b.class,
+		field: FieldInfo{
+			AccessFlags:     accessFlags,
+			NameIndex:       nameIndex,
+			DescriptorIndex: descriptorIndex,
+		},
+		attributeBuilder: attributeBuilder{
+			attributes: []AttributeInfo{},
+			names:      b.names,
+		},
+	}
+}
+
+func (b *ClassBuilder) VisitMethod(accessFlags MethodAccess, nameIndex, descriptorIndex uint16) MethodVisitor {
+	return &methodBuilder{
+		class: &b.class,
+		method: MethodInfo{
+			AccessFlags:     accessFlags,
+			NameIndex:       nameIndex,
+			DescriptorIndex: descriptorIndex,
+		},
+		attributeBuilder: attributeBuilder{
+			attributes: []AttributeInfo{},
+			names:      b.names,
+		},
+	}
+}
+
+func (b *ClassBuilder) VisitEnd() {
+	b.class.Attributes = b.attributes
+}
+
+type fieldBuilder struct {
+	class *Class
+	field FieldInfo
+	attributeBuilder
+}
+
+func (b *fieldBuilder) VisitEnd() {
+	b.field.Attributes = b.attributes
+	b.class.Fields = append(b.class.Fields, b.field)
+}
+
+type methodBuilder struct {
+	class  *Class
+	method MethodInfo
+	attributeBuilder
+}
+
+func (b *methodBuilder) VisitCode(maxStack, maxLocals uint16) CodeVisitor {
+	return &codeBuilder{
+		method: b,
+		code: CodeAttribute{
+			AttributeNameIndex: AttributeNameIndex(b.names[AttrCode]),
+			MaxStack:           maxStack,
+			MaxLocals:          maxLocals,
+			ExceptionTable:     []Exception{},
+			Attributes:         []AttributeInfo{},
+		},
+	}
+}
+
+func (b *methodBuilder) VisitEnd() {
+	b.method.Attributes = b.attributes
+	b.class.Methods = append(b.class.Methods, b.method)
+}
+
+type codeBuilder struct {
+	method *methodBuilder
+	code   CodeAttribute
+}
+
+func (b *codeBuilder) VisitBytecode(code []byte) {
+	b.code.Code = code
+}
+
+func (b *codeBuilder) VisitExceptionHandler(e Exception) {
+	b.code.ExceptionTable = append(b.code.ExceptionTable, e)
+}
+
+func (b *codeBuilder) VisitAttribute(a AttributeInfo) {
+	b.code.Attributes = append(b.code.Attributes, a)
+}
+
+func (b *codeBuilder) VisitEnd() {
+	b.method.attributes = append(b.method.attributes, b.code)
+}
+
+type attributeBuilder struct {
+	attributes []AttributeInfo
+	names      map[string]uint16
+}
+
+func (b *attributeBuilder) VisitAttribute(a AttributeInfo) {
+	b.attributes = append(b.attributes, a)
+}
+
+func (b *attributeBuilder) VisitAnnotation(typeIndex uint16, visible bool) AnnotationVisitor {
+	return &annotationBuilder{
+		pairs: []ElementValuePair{},
+		done: func(pairs []ElementValuePair) {
+			b.addAnnotation(Annotation{
+				TypeIndex:         typeIndex,
+				ElementValuePairs: pairs,
+			}, visible)
+		},
+	}
+}
+
+// addAnnotation appends an annotation to the annotations attribute of the
+// given visibility, which is created if it does not already exist.
+func (b *attributeBuilder) addAnnotation(annotation Annotation, visible bool) {
+	for n, attribute := range b.attributes {
+		switch a := attribute.(type) {
+		case RuntimeVisibleAnnotationsAttribute:
+			if visible {
+				a.Annotations = append(a.Annotations, annotation)
+				b.attributes[n] = a
+				return
+			}
+		case RuntimeInvisibleAnnotationsAttribute:
+			if !visible {
+				a.Annotations = append(a.Annotations, annotation)
+				b.attributes[n] = a
+				return
+			}
+		}
+	}
+	if visible {
+		b.attributes = append(b.attributes, RuntimeVisibleAnnotationsAttribute{AttributeNameIndex(b.names[AttrRuntimeVisibleAnnotations]), []Annotation{annotation}})
+	} else {
+		b.attributes = append(b.attributes, RuntimeInvisibleAnnotationsAttribute{AttributeNameIndex(b.names[AttrRuntimeInvisibleAnnotations]), []Annotation{annotation}})
+	}
+}
+
+type annotationBuilder struct {
+	pairs []ElementValuePair
+	done  func([]ElementValuePair)
+}
+
+func (b *annotationBuilder) VisitValue(nameIndex uint16, value ElementValue) {
+	b.pairs = append(b.pairs, ElementValuePair{
+		ElementNameIndex: nameIndex,
+		Value:            value,
+	})
+}
+
+func (b *annotationBuilder) VisitAnnotation(nameIndex, typeIndex uint16) AnnotationVisitor {
+	return &annotationBuilder{
+		pairs: []ElementValuePair{},
+		done: func(pairs []ElementValuePair) {
+			b.VisitValue(nameIndex, AnnotationValue{Annotation{
+				TypeIndex:         typeIndex,
+				ElementValuePairs: pairs,
+			}})
+		},
+	}
+}
+
+func (b *annotationBuilder) VisitArray(nameIndex uint16) AnnotationVisitor {
+	return &annotationBuilder{
+		pairs: []ElementValuePair{},
+		done: func(pairs []ElementValuePair) {
+			values := make([]ElementValue, len(pairs))
+			for n, p := range pairs {
+				values[n] = p.Value
+			}
+			b.VisitValue(nameIndex, ArrayValue{values})
+		},
+	}
+}
+
+func (b *annotationBuilder) VisitEnd() {
+	b.done(b.pairs)
+}
@@ -0,0 +1,81 @@
+package javaclass
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+type dropMethods struct {
+	ClassVisitor
+}
+
+func (dropMethods) VisitMethod(accessFlags MethodAccess, nameIndex, descriptorIndex uint16) MethodVisitor {
+	return nil
+}
+
+type dropCode struct {
+	ClassVisitor
+}
+
+func (d dropCode) VisitMethod(accessFlags MethodAccess, nameIndex, descriptorIndex uint16) MethodVisitor {
+	return dropCodeMethod{d.ClassVisitor.VisitMethod(accessFlags, nameIndex, descriptorIndex)}
+}
+
+type dropCodeMethod struct {
+	MethodVisitor
+}
+
+func (dropCodeMethod) VisitCode(maxStack, maxLocals uint16) CodeVisitor {
+	return nil
+}
+
+func TestAccept(t *testing.T) {
+	for n, seed := range seedClassFiles() {
+		expected, err := Read(bytes.NewReader(seed))
+		if err != nil {
+			t.Fatalf("test %d: unexpected error reading class: %s", n+1, err)
+		}
+		var b ClassBuilder
+		if err := Accept(bytes.NewReader(seed), &b); err != nil {
+			t.Errorf("test %d: unexpected error: %s", n+1, err)
+		} else if !reflect.DeepEqual(b.Class(), expected) {
+			t.Errorf("test %d: built class differs from read class", n+1)
+		}
+		b = ClassBuilder{}
+		expected.Accept(&b)
+		if !reflect.DeepEqual(b.Class(), expected) {
+			t.Errorf("test %d: class rebuilt from tree differs from read class", n+1)
+		}
+		for i := range seed {
+			_, readErr := Read(bytes.NewReader(seed[:i]))
+			err := Accept(bytes.NewReader(seed[:i]), new(ClassBuilder))
+			var pe *ParseError
+			if (err == nil) != (readErr == nil) || err != nil && !errors.As(err, &pe) {
+				t.Errorf("test %d: accepting %d bytes: expecting error like %q, got %q", n+1, i, readErr, err)
+			}
+		}
+	}
+}
+
+func TestAcceptPipeline(t *testing.T) {
+	seed := seedClassFiles()[0]
+	expected, err := ReadOptions{SkipCode: true}.Read(bytes.NewReader(seed))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var b ClassBuilder
+	if err := Accept(bytes.NewReader(seed), dropCode{&b}); err != nil {
+		t.Fatal(err)
+	} else if !reflect.DeepEqual(b.Class(), expected) {
+		t.Errorf("class without code differs from class read with SkipCode")
+	}
+	expected.Methods = []MethodInfo{}
+	b = ClassBuilder{}
+	if err := Accept(bytes.NewReader(seed), dropMethods{&b}); err != nil {
+		t.Fatal(err)
+	} else if !reflect.DeepEqual(b.Class(), expected) {
+		t.Errorf("class without methods differs from expected")
+	}
+}